@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+type Abilities struct {
+	CanFly         bool
+	Flying         bool
+	NoClip         bool
+	Muted          bool
+	WorldImmutable bool
+	CanBuild       bool
+	CanMine        bool
+	CanAttack      bool
+	Operator       bool
+	// CommandPermissionLevel is one of the packet.CommandPermissionLevel constants
+	CommandPermissionLevel uint32
+}
+
+// selfAbilities are the bot abilities from the last AdventureSettings the server sent
+var selfAbilities Abilities
+
+func abilitiesFromSettings(pk *packet.AdventureSettings) Abilities {
+	return Abilities{
+		CanFly:                 pk.Flags&packet.AdventureFlagAllowFlight != 0,
+		Flying:                 pk.Flags&packet.AdventureFlagFlying != 0,
+		NoClip:                 pk.Flags&packet.AdventureFlagNoClip != 0,
+		Muted:                  pk.Flags&packet.AdventureFlagMuted != 0,
+		WorldImmutable:         pk.Flags&packet.AdventureFlagWorldImmutable != 0,
+		CanBuild:               pk.ActionPermissions&packet.ActionPermissionBuild != 0,
+		CanMine:                pk.ActionPermissions&packet.ActionPermissionMine != 0,
+		CanAttack:              pk.ActionPermissions&packet.ActionPermissionAttackPlayers != 0,
+		Operator:               pk.ActionPermissions&packet.ActionPermissionOperator != 0,
+		CommandPermissionLevel: pk.CommandPermissionLevel,
+	}
+}
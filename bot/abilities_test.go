@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestAbilitiesFromSettings(t *testing.T) {
+	got := abilitiesFromSettings(&packet.AdventureSettings{
+		Flags:                  packet.AdventureFlagAllowFlight | packet.AdventureFlagMuted,
+		ActionPermissions:      packet.ActionPermissionMine | packet.ActionPermissionOperator,
+		CommandPermissionLevel: packet.CommandPermissionLevelHost,
+	})
+	want := Abilities{
+		CanFly:                 true,
+		Muted:                  true,
+		CanMine:                true,
+		Operator:               true,
+		CommandPermissionLevel: packet.CommandPermissionLevelHost,
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := abilitiesFromSettings(&packet.AdventureSettings{}); got != (Abilities{}) {
+		t.Errorf("no flags gave %+v, want no abilities", got)
+	}
+}
+
+func TestAdventureSettingsOfOthers(t *testing.T) {
+	conn := startTestSession(t)
+	handlePacket(conn, &packet.AdventureSettings{PlayerUniqueID: 1, Flags: packet.AdventureFlagFlying})
+	handlePacket(conn, &packet.AdventureSettings{PlayerUniqueID: 2, Flags: packet.AdventureFlagNoClip})
+	if !selfAbilities.Flying || selfAbilities.NoClip {
+		t.Errorf("abilities are %+v, want only the bot's settings applied", selfAbilities)
+	}
+}
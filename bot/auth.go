@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/racerxdl/minebot/config"
+	"github.com/sandertv/gophertunnel/minecraft/auth"
+	"golang.org/x/oauth2"
+)
+
+// AuthProvider provides the Xbox Live token source the bot logs in with
+type AuthProvider interface {
+	Token() (oauth2.TokenSource, error)
+}
+
+// authProvider is the AuthProvider used by main
+var authProvider AuthProvider = DeviceCodeAuth{OnPrompt: logAuthPrompt}
+
+// DeviceCodeAuth loads the token saved in config.TokenStore, or asks for a new one with the device
+// code flow and saves it. The device code prompts are passed to OnPrompt instead of stdout.
+type DeviceCodeAuth struct {
+	OnPrompt func(msg string)
+}
+
+// promptWriter passes each line written to it to a prompt callback
+type promptWriter func(msg string)
+
+func (w promptWriter) Write(p []byte) (int, error) {
+	w(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (a DeviceCodeAuth) Token() (oauth2.TokenSource, error) {
+	tkn, err := config.LoadToken()
+	if err != nil {
+		tkn, err = auth.RequestLiveTokenWriter(promptWriter(a.OnPrompt))
+		if err != nil {
+			return nil, err
+		}
+		if err := config.SaveToken(tkn); err != nil {
+			return nil, fmt.Errorf("error saving token: %w", err)
+		}
+	}
+	return auth.RefreshTokenSource(tkn), nil
+}
+
+// pendingAuth is the last device code prompt, reported by the Status RPC until the login completes
+var pendingAuth struct {
+	lock   sync.Mutex
+	prompt string
+}
+
+// logAuthPrompt logs the device code prompts as warnings, so they show up when running under a
+// supervisor, and keeps the pending one for the Status RPC
+func logAuthPrompt(msg string) {
+	log.Warnf("Xbox Live login: %s\n", msg)
+	pendingAuth.lock.Lock()
+	defer pendingAuth.lock.Unlock()
+	if strings.HasPrefix(msg, "Authenticate") {
+		pendingAuth.prompt = msg
+	} else {
+		pendingAuth.prompt = ""
+	}
+}
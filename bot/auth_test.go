@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// stubAuth is an AuthProvider returning a fixed token, or err
+type stubAuth struct {
+	calls *int
+	err   error
+}
+
+func (a stubAuth) Token() (oauth2.TokenSource, error) {
+	*a.calls++
+	if a.err != nil {
+		return nil, a.err
+	}
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "stub"}), nil
+}
+
+func useAuth(t *testing.T, a AuthProvider) {
+	t.Helper()
+	old := authProvider
+	authProvider = a
+	t.Cleanup(func() { authProvider = old })
+}
+
+func TestTokenSource(t *testing.T) {
+	setTestConfig(t, "")
+	calls := 0
+	useAuth(t, stubAuth{calls: &calls})
+	src, err := tokenSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tkn, err := src.Token(); err != nil || tkn.AccessToken != "stub" {
+		t.Errorf("token source gave %v, %v, want the stub token", tkn, err)
+	}
+
+	// The token source is the one the bot dials with
+	var dialed oauth2.TokenSource
+	fakeDialer(t, newFakeConn())
+	inner := dialServer
+	dialServer = func(src oauth2.TokenSource) (ServerConn, error) {
+		dialed = src
+		return inner(src)
+	}
+	if connect(src, make(chan struct{})) == nil || dialed != src {
+		t.Error("connect didn't dial with the provided token source")
+	}
+
+	errAuth := errors.New("login refused")
+	useAuth(t, stubAuth{calls: &calls, err: errAuth})
+	if _, err := tokenSource(); err != errAuth {
+		t.Errorf("token source error is %v, want %v", err, errAuth)
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want 2", calls)
+	}
+}
+
+func TestTokenSourceOffline(t *testing.T) {
+	setTestConfig(t, "[Identity]\nOffline = true")
+	calls := 0
+	useAuth(t, stubAuth{calls: &calls})
+	if src, err := tokenSource(); src != nil || err != nil {
+		t.Errorf("offline token source is %v, %v, want none", src, err)
+	}
+	if calls != 0 {
+		t.Error("the auth provider was asked for a token offline")
+	}
+}
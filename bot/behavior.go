@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"errors"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Behavior is something the bot does on its own. Behaviors that move the bot compete for movement,
+// only the one with the highest priority among those that want to run is started. Other behaviors
+// run whenever they want to.
+type Behavior interface {
+	Name() string
+	Priority() int
+	// Movement tells whether the behavior moves the bot
+	Movement() bool
+	// Wants tells whether the behavior wants to run right now
+	Wants() bool
+	Start(conn ServerConn)
+	Stop()
+	Tick(conn ServerConn)
+}
+
+var errUnknownBehavior = errors.New("unknown behavior")
+
+type scheduler struct {
+	behaviors []Behavior
+	running   map[Behavior]bool
+	// disabled behaviors never start, they are toggled with !behavior
+	disabled map[Behavior]bool
+	// safeMode holds the behaviors that panicked. They are disabled until enabled again.
+	safeMode map[Behavior]bool
+	mover    Behavior
+	// ReadyAt is when the world finished loading, no behavior starts before it is set
+	ReadyAt time.Time
+	// Stagger spreads the behavior starts over this long after ReadyAt, in registration order
+	Stagger time.Duration
+	// MovementPaused keeps every movement behavior stopped, while the connection is too slow
+	MovementPaused bool
+	// PausedUntil keeps every movement behavior stopped until then, after a movement desync
+	PausedUntil time.Time
+	// Riding keeps every movement behavior stopped while the bot is in a vehicle, as it moves with
+	// different packets
+	Riding bool
+}
+
+// behaviors runs the registered behaviors, ticked by the TX loop
+var behaviors = newScheduler()
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		running:  map[Behavior]bool{},
+		disabled: map[Behavior]bool{},
+		safeMode: map[Behavior]bool{},
+	}
+}
+
+func (s *scheduler) Register(b Behavior) {
+	s.behaviors = append(s.behaviors, b)
+}
+
+// wants tells whether the behavior at index i wants to run and is past its staggered start
+func (s *scheduler) wants(i int) bool {
+	if s.ReadyAt.IsZero() || s.disabled[s.behaviors[i]] || s.safeMode[s.behaviors[i]] {
+		return false
+	}
+	offset := s.Stagger * time.Duration(i) / time.Duration(len(s.behaviors))
+	return time.Since(s.ReadyAt) >= offset && s.behaviors[i].Wants()
+}
+
+// Tick starts, stops and ticks the behaviors. A movement behavior is preempted as soon as one with
+// a higher priority wants to run.
+func (s *scheduler) Tick(conn ServerConn) {
+	var mover Behavior
+	for i, b := range s.behaviors {
+		wants := s.wants(i)
+		if b.Movement() {
+			if wants && !s.MovementPaused && !s.Riding && !time.Now().Before(s.PausedUntil) && (mover == nil || b.Priority() > mover.Priority()) {
+				mover = b
+			}
+			continue
+		}
+		if wants != s.running[b] {
+			s.toggle(conn, b, wants)
+		}
+	}
+
+	if mover != s.mover {
+		if s.mover != nil {
+			if mover != nil {
+				log.Infof("Behavior %s preempted by %s\n", s.mover.Name(), mover.Name())
+			}
+			s.toggle(conn, s.mover, false)
+		}
+		s.mover = mover
+		if mover != nil {
+			s.toggle(conn, mover, true)
+		}
+	}
+
+	for _, b := range s.behaviors {
+		if s.running[b] {
+			s.call(b, func() { b.Tick(conn) })
+		}
+	}
+}
+
+// call runs fn for b. If it panics, b is put in safe mode instead of taking the TX loop down, and
+// false is returned.
+func (s *scheduler) call(b Behavior, fn func()) (ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Behavior %s panicked, entering safe mode: %v\n%s", b.Name(), err, debug.Stack())
+			s.safeMode[b] = true
+			s.running[b] = false
+			if s.mover == b {
+				s.mover = nil
+			}
+			ok = false
+		}
+	}()
+	fn()
+	return true
+}
+
+// SetEnabled enables or disables the behavior named name. A disabled behavior that is running is
+// stopped on the next Tick.
+func (s *scheduler) SetEnabled(name string, enabled bool) error {
+	for _, b := range s.behaviors {
+		if strings.EqualFold(b.Name(), name) {
+			s.disabled[b] = !enabled
+			if enabled {
+				delete(s.safeMode, b)
+			}
+			return nil
+		}
+	}
+	return errUnknownBehavior
+}
+
+// BehaviorStatus describes a registered behavior
+type BehaviorStatus struct {
+	Name     string
+	Priority int
+	Running  bool
+	Enabled  bool
+	SafeMode bool
+}
+
+// Status lists the registered behaviors
+func (s *scheduler) Status() []BehaviorStatus {
+	status := make([]BehaviorStatus, len(s.behaviors))
+	for i, b := range s.behaviors {
+		status[i] = BehaviorStatus{
+			Name:     b.Name(),
+			Priority: b.Priority(),
+			Running:  s.running[b],
+			Enabled:  !s.disabled[b],
+			SafeMode: s.safeMode[b],
+		}
+	}
+	return status
+}
+
+// Cancel stops the behavior named name right away and disables it until it is enabled again
+func (s *scheduler) Cancel(name string) error {
+	for _, b := range s.behaviors {
+		if strings.EqualFold(b.Name(), name) {
+			s.disabled[b] = true
+			if s.running[b] {
+				s.toggle(nil, b, false)
+			}
+			if s.mover == b {
+				s.mover = nil
+			}
+			return nil
+		}
+	}
+	return errUnknownBehavior
+}
+
+// StopAll stops every running behavior, they start again on the next Tick if they still want to
+func (s *scheduler) StopAll() {
+	for _, b := range s.behaviors {
+		if s.running[b] {
+			s.toggle(nil, b, false)
+		}
+	}
+	s.mover = nil
+}
+
+func (s *scheduler) toggle(conn ServerConn, b Behavior, run bool) {
+	if run {
+		log.Infof("Starting behavior %s\n", b.Name())
+		if !s.call(b, func() { b.Start(conn) }) {
+			return
+		}
+	} else {
+		log.Infof("Stopping behavior %s\n", b.Name())
+		if !s.call(b, b.Stop) {
+			return
+		}
+	}
+	s.running[b] = run
+}
@@ -0,0 +1,319 @@
+package bot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// fakeBehavior counts its calls. It wants to run while wants is set, and panics in the call named
+// by panicIn.
+type fakeBehavior struct {
+	name     string
+	priority int
+	movement bool
+	wants    bool
+	panicIn  string
+
+	starts, stops, ticks int
+}
+
+func (b *fakeBehavior) Name() string   { return b.name }
+func (b *fakeBehavior) Priority() int  { return b.priority }
+func (b *fakeBehavior) Movement() bool { return b.movement }
+func (b *fakeBehavior) Wants() bool    { return b.wants }
+
+func (b *fakeBehavior) Start(conn ServerConn) {
+	b.starts++
+	b.maybePanic("start")
+}
+
+func (b *fakeBehavior) Stop() {
+	b.stops++
+	b.maybePanic("stop")
+}
+
+func (b *fakeBehavior) Tick(conn ServerConn) {
+	b.ticks++
+	b.maybePanic("tick")
+}
+
+func (b *fakeBehavior) maybePanic(call string) {
+	if b.panicIn == call {
+		panic(b.name + " broke in " + call)
+	}
+}
+
+// readyScheduler returns a scheduler with bs registered, with the world loaded and no stagger
+func readyScheduler(bs ...Behavior) *scheduler {
+	s := newScheduler()
+	for _, b := range bs {
+		s.Register(b)
+	}
+	s.ReadyAt = time.Now().Add(-time.Second)
+	return s
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	patrol := &fakeBehavior{name: "patrol", priority: 1, movement: true, wants: true}
+	follow := &fakeBehavior{name: "follow", priority: 5, movement: true, wants: true}
+	collect := &fakeBehavior{name: "collect", priority: 3, movement: true, wants: true}
+	s := readyScheduler(patrol, follow, collect)
+
+	s.Tick(nil)
+	s.Tick(nil)
+	if follow.starts != 1 || follow.ticks != 2 {
+		t.Errorf("follow started %d times and ticked %d times, want 1 and 2", follow.starts, follow.ticks)
+	}
+	if patrol.starts != 0 || collect.starts != 0 {
+		t.Errorf("lower priority movement behaviors started: patrol %d, collect %d", patrol.starts, collect.starts)
+	}
+
+	// The next best one takes over once follow doesn't want to run anymore
+	follow.wants = false
+	s.Tick(nil)
+	if follow.stops != 1 || collect.starts != 1 || patrol.starts != 0 {
+		t.Errorf("follow stopped %d, collect started %d, patrol started %d times, want 1, 1 and 0", follow.stops, collect.starts, patrol.starts)
+	}
+}
+
+func TestSchedulerPreemption(t *testing.T) {
+	patrol := &fakeBehavior{name: "patrol", priority: 1, movement: true, wants: true}
+	flee := &fakeBehavior{name: "flee", priority: 10, movement: true}
+	s := readyScheduler(patrol, flee)
+
+	s.Tick(nil)
+	if !s.running[patrol] {
+		t.Fatal("patrol is not running")
+	}
+	flee.wants = true
+	s.Tick(nil)
+	if s.running[patrol] || patrol.stops != 1 {
+		t.Errorf("patrol still running after flee wanted to run (stopped %d times)", patrol.stops)
+	}
+	if !s.running[flee] || s.mover != flee {
+		t.Error("flee didn't preempt patrol")
+	}
+	flee.wants = false
+	s.Tick(nil)
+	if !s.running[patrol] || patrol.starts != 2 {
+		t.Errorf("patrol not resumed after flee ended (started %d times)", patrol.starts)
+	}
+}
+
+func TestSchedulerConcurrent(t *testing.T) {
+	greeter := &fakeBehavior{name: "greeter", wants: true}
+	announcer := &fakeBehavior{name: "announcer", wants: true}
+	patrol := &fakeBehavior{name: "patrol", priority: 1, movement: true, wants: true}
+	s := readyScheduler(greeter, announcer, patrol)
+
+	s.Tick(nil)
+	for _, b := range []*fakeBehavior{greeter, announcer, patrol} {
+		if !s.running[b] || b.ticks != 1 {
+			t.Errorf("%s running %v, ticked %d times, want running and 1", b.name, s.running[b], b.ticks)
+		}
+	}
+
+	// Pausing the movement leaves the other behaviors running
+	s.MovementPaused = true
+	s.Tick(nil)
+	if s.running[patrol] || !s.running[greeter] || !s.running[announcer] {
+		t.Errorf("paused movement: patrol %v, greeter %v, announcer %v", s.running[patrol], s.running[greeter], s.running[announcer])
+	}
+	greeter.wants = false
+	s.Tick(nil)
+	if s.running[greeter] || greeter.stops != 1 || !s.running[announcer] {
+		t.Errorf("greeter running %v after it stopped wanting to, stopped %d times", s.running[greeter], greeter.stops)
+	}
+}
+
+func TestSchedulerPauses(t *testing.T) {
+	patrol := &fakeBehavior{name: "patrol", priority: 1, movement: true, wants: true}
+	tests := []struct {
+		name  string
+		pause func(s *scheduler)
+	}{
+		{"slow connection", func(s *scheduler) { s.MovementPaused = true }},
+		{"riding", func(s *scheduler) { s.Riding = true }},
+		{"desync", func(s *scheduler) { s.PausedUntil = time.Now().Add(time.Hour) }},
+	}
+	for _, test := range tests {
+		s := readyScheduler(patrol)
+		test.pause(s)
+		s.Tick(nil)
+		if s.running[patrol] {
+			t.Errorf("%s: patrol running while paused", test.name)
+		}
+	}
+}
+
+func TestSchedulerStagger(t *testing.T) {
+	first := &fakeBehavior{name: "first", wants: true}
+	second := &fakeBehavior{name: "second", wants: true}
+	s := readyScheduler(first, second)
+
+	s.ReadyAt = time.Time{}
+	s.Tick(nil)
+	if first.starts != 0 || second.starts != 0 {
+		t.Error("behaviors started before the world loaded")
+	}
+
+	// The second of two behaviors starts half the stagger window after the world loaded
+	s.Stagger = time.Minute
+	s.ReadyAt = time.Now().Add(-time.Second)
+	s.Tick(nil)
+	if first.starts != 1 || second.starts != 0 {
+		t.Errorf("first started %d times and second %d times, want 1 and 0", first.starts, second.starts)
+	}
+	s.ReadyAt = time.Now().Add(-31 * time.Second)
+	s.Tick(nil)
+	if second.starts != 1 {
+		t.Errorf("second started %d times after half the window, want 1", second.starts)
+	}
+}
+
+func TestSchedulerPanic(t *testing.T) {
+	greeter := &fakeBehavior{name: "greeter", wants: true, panicIn: "tick"}
+	patrol := &fakeBehavior{name: "patrol", priority: 1, movement: true, wants: true, panicIn: "start"}
+	announcer := &fakeBehavior{name: "announcer", wants: true}
+	s := readyScheduler(greeter, patrol, announcer)
+
+	for i := 0; i < 3; i++ {
+		s.Tick(nil)
+	}
+	if greeter.ticks != 1 || patrol.starts != 1 {
+		t.Errorf("greeter ticked %d times and patrol started %d times, want 1 each before safe mode", greeter.ticks, patrol.starts)
+	}
+	if announcer.ticks != 3 {
+		t.Errorf("announcer ticked %d times, want 3 while the others panicked", announcer.ticks)
+	}
+	for _, v := range s.Status() {
+		if want := v.Name != "announcer"; v.SafeMode != want || v.Running == want {
+			t.Errorf("%s in safe mode %v and running %v, want safe mode %v", v.Name, v.SafeMode, v.Running, want)
+		}
+	}
+
+	// Enabling it again leaves safe mode
+	greeter.panicIn = ""
+	if err := s.SetEnabled("greeter", true); err != nil {
+		t.Fatal(err)
+	}
+	s.Tick(nil)
+	if !s.running[greeter] || greeter.ticks != 2 {
+		t.Errorf("greeter running %v and ticked %d times after leaving safe mode", s.running[greeter], greeter.ticks)
+	}
+}
+
+// useScheduler replaces the bot's scheduler with s for the duration of the test
+func useScheduler(t *testing.T, s *scheduler) {
+	t.Helper()
+	old := behaviors
+	behaviors = s
+	t.Cleanup(func() { behaviors = old })
+}
+
+// runAdminCommand runs the chat command msg as Admin, an allowed user, and returns the replies
+func runAdminCommand(t *testing.T, conn ServerConn, msg string) []string {
+	t.Helper()
+	drainChat()
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Admin", Message: msg})
+	return drainChat()
+}
+
+func TestBehaviorToggle(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Connection]\nAllowedNames = [\"Admin\"]\n")
+	guard := &fakeBehavior{name: "guard", wants: true}
+	s := readyScheduler(guard)
+	useScheduler(t, s)
+
+	s.Tick(conn)
+	if msgs := runAdminCommand(t, conn, "!behavior Guard off"); len(msgs) != 1 || msgs[0] != "Behavior Guard is off" {
+		t.Errorf("got replies %q", msgs)
+	}
+	s.Tick(conn)
+	if s.running[guard] || guard.stops != 1 {
+		t.Errorf("guard running %v after being disabled, stopped %d times", s.running[guard], guard.stops)
+	}
+	if msgs := runAdminCommand(t, conn, "!behavior guard on"); len(msgs) != 1 || msgs[0] != "Behavior guard is on" {
+		t.Errorf("got replies %q", msgs)
+	}
+	s.Tick(conn)
+	if !s.running[guard] || guard.starts != 2 {
+		t.Errorf("guard running %v after being enabled, started %d times", s.running[guard], guard.starts)
+	}
+
+	if msgs := runAdminCommand(t, conn, "!behavior patrol off"); len(msgs) != 1 || msgs[0] != "Can't toggle patrol: unknown behavior" {
+		t.Errorf("got replies %q for an unknown behavior", msgs)
+	}
+	if msgs := runAdminCommand(t, conn, "!behavior guard maybe"); len(msgs) != 1 || !strings.HasPrefix(msgs[0], "Usage") {
+		t.Errorf("got replies %q for an invalid state", msgs)
+	}
+
+	// Only allowed users can toggle behaviors
+	drainChat()
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "!behavior guard off"})
+	if msgs := drainChat(); len(msgs) != 0 || s.disabled[guard] {
+		t.Errorf("Steve toggled guard, replies %q", msgs)
+	}
+
+	if err := (BotRPC{}).SetBehavior(BehaviorToggle{Name: "guard", Enabled: false}, &Empty{}); err != nil || !s.disabled[guard] {
+		t.Errorf("SetBehavior RPC: %v, disabled %v", err, s.disabled[guard])
+	}
+	if err := (BotRPC{}).SetBehavior(BehaviorToggle{Name: "patrol"}, &Empty{}); err != errUnknownBehavior {
+		t.Errorf("SetBehavior RPC of an unknown behavior returned %v", err)
+	}
+}
+
+func TestBehaviorsListAndCancel(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Connection]\nAllowedNames = [\"Admin\"]\n")
+	patrol := &fakeBehavior{name: "patrol", priority: 1, movement: true, wants: true}
+	flee := &fakeBehavior{name: "flee", priority: 10, movement: true}
+	greeter := &fakeBehavior{name: "greeter", wants: true}
+	s := readyScheduler(patrol, flee, greeter)
+	useScheduler(t, s)
+
+	if msgs := runAdminCommand(t, conn, "!behaviors"); len(msgs) != 1 || msgs[0] != "No behavior is running" {
+		t.Errorf("got replies %q before the first tick", msgs)
+	}
+	s.Tick(conn)
+	if msgs := runAdminCommand(t, conn, "!behaviors"); len(msgs) != 1 || msgs[0] != "Running: patrol (1), greeter (0)" {
+		t.Errorf("got replies %q", msgs)
+	}
+	flee.wants = true
+	s.Tick(conn)
+	if msgs := runAdminCommand(t, conn, "!behaviors"); len(msgs) != 1 || msgs[0] != "Running: flee (10), greeter (0)" {
+		t.Errorf("got replies %q after flee preempted patrol", msgs)
+	}
+
+	if msgs := runAdminCommand(t, conn, "!cancel flee"); len(msgs) != 1 || !strings.HasPrefix(msgs[0], "Cancelled flee") {
+		t.Errorf("got replies %q", msgs)
+	}
+	if s.running[flee] || flee.stops != 1 {
+		t.Errorf("flee running %v after the cancel, stopped %d times", s.running[flee], flee.stops)
+	}
+	s.Tick(conn)
+	if !s.running[patrol] {
+		t.Error("patrol didn't take over after flee was cancelled")
+	}
+	if msgs := runAdminCommand(t, conn, "!cancel follow"); len(msgs) != 1 || msgs[0] != "Can't cancel follow: unknown behavior" {
+		t.Errorf("got replies %q for an unknown behavior", msgs)
+	}
+
+	var status []BehaviorStatus
+	if err := (BotRPC{}).Behaviors(Empty{}, &status); err != nil {
+		t.Fatal(err)
+	}
+	want := []BehaviorStatus{
+		{Name: "patrol", Priority: 1, Running: true, Enabled: true},
+		{Name: "flee", Priority: 10},
+		{Name: "greeter", Running: true, Enabled: true},
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("Behaviors RPC returned %+v, want %+v", status, want)
+	}
+}
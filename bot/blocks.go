@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// blockPalette is the file format for block runtime ID names. Runtime IDs change between protocol
+// versions, so the file records the version it was generated for.
+type blockPalette struct {
+	Protocol int32
+	Blocks   map[uint32]string
+}
+
+// blockNames maps block runtime IDs to names, loaded from the configured palette file
+var blockNames = map[uint32]string{}
+
+// loadBlockNames loads the block palette at path into blockNames
+func loadBlockNames(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	palette := blockPalette{}
+	if err := json.Unmarshal(data, &palette); err != nil {
+		return err
+	}
+	if palette.Protocol != protocol.CurrentProtocol {
+		return fmt.Errorf("block palette is for protocol %d, but the bot speaks %d", palette.Protocol, protocol.CurrentProtocol)
+	}
+	blockNames = palette.Blocks
+	log.Infof("Loaded %d block names\n", len(blockNames))
+	return nil
+}
+
+func blockName(runtimeID uint32) string {
+	if name, ok := blockNames[runtimeID]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown block %d", runtimeID)
+}
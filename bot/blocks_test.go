@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+func TestLoadBlockNames(t *testing.T) {
+	t.Cleanup(func() { blockNames = map[uint32]string{} })
+	dir := t.TempDir()
+	path := filepath.Join(dir, "palette.json")
+	palette := fmt.Sprintf(`{"Protocol": %d, "Blocks": {"0": "minecraft:air", "1": "minecraft:stone"}}`, protocol.CurrentProtocol)
+	if err := os.WriteFile(path, []byte(palette), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadBlockNames(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := blockName(1); got != "minecraft:stone" {
+		t.Errorf("blockName(1) = %q", got)
+	}
+	if got := blockName(99); got != "unknown block 99" {
+		t.Errorf("blockName(99) = %q", got)
+	}
+
+	old := filepath.Join(dir, "old.json")
+	if err := os.WriteFile(old, []byte(`{"Protocol": 1, "Blocks": {"1": "minecraft:grass"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadBlockNames(old); err == nil {
+		t.Error("loaded a palette for another protocol version")
+	}
+	if got := blockName(1); got != "minecraft:stone" {
+		t.Errorf("blockName(1) = %q after a failed load, want the names kept", got)
+	}
+}
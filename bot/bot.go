@@ -0,0 +1,1001 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	stdlog "log"
+	"math"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/racerxdl/minebot/config"
+	"github.com/racerxdl/minebot/lang"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sandertv/gophertunnel/minecraft/text"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var log = logrus.New()
+var cfg config.Config
+
+// plainChat logs chat without formatting instead of converting it to ANSI colours
+var plainChat = false
+
+// formatChat renders msg for the log according to plainChat
+func formatChat(msg string) string {
+	if plainChat {
+		return lang.StripFormatting(msg)
+	}
+	return text.ANSI(msg)
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+type Player struct {
+	Username        string
+	EntityRuntimeID uint64
+	EntityGlobalID  int64
+	Position        mgl32.Vec3
+	LastSeen        time.Time
+	InCombatRange   bool
+}
+
+// selfRuntimeID and selfPosition track the bot itself, updated when the server moves it
+var selfRuntimeID uint64
+var selfPosition mgl32.Vec3
+
+// selfAttributes holds the last attributes the server sent for the bot, keyed by name
+var selfAttributes = map[string]protocol.Attribute{}
+
+type ServerInfo struct {
+	WorldName   string
+	GameVersion string
+	Difficulty  int32
+	GameMode    int32
+}
+
+// serverInfo describes the server the bot is connected to, taken from its StartGame
+var serverInfo ServerInfo
+
+// dimension is the dimension the bot is currently in: 0 overworld, 1 nether, 2 end
+var dimension int32
+
+// selfVehicle is the unique ID of the entity the bot rides, zero when it isn't in a vehicle
+var selfVehicle int64
+
+// worldSpawn is the world spawn point, taken from StartGame and updated by SetSpawnPosition
+var worldSpawn protocol.BlockPos
+
+var players = map[uint64]*Player{}
+
+// playersLock guards players, which is updated by the RX loop and swept by the TX loop
+var playersLock sync.Mutex
+
+// lastKnown keeps the last state of players that left the tracked range, keyed by lowercase username
+var lastKnown = map[string]Player{}
+
+// removePlayer stops tracking the player with runtime id, remembering it in lastKnown
+func removePlayer(id uint64) {
+	if player, ok := players[id]; ok {
+		lastKnown[strings.ToLower(player.Username)] = *player
+		delete(players, id)
+	}
+}
+
+// resetPlayers stops tracking all players
+func resetPlayers() {
+	for id := range players {
+		removePlayer(id)
+	}
+}
+
+// findPlayer returns the tracked player with username, or its last known state. live is true if
+// the player is currently tracked.
+func findPlayer(username string) (player Player, live bool, ok bool) {
+	for _, v := range players {
+		if strings.EqualFold(v.Username, username) {
+			return *v, true, true
+		}
+	}
+	player, ok = lastKnown[strings.ToLower(username)]
+	return player, false, ok
+}
+
+// evictStalePlayers removes players that were not seen for longer than ttl
+func evictStalePlayers(ttl time.Duration) {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	for id, player := range players {
+		if time.Since(player.LastSeen) > ttl {
+			removePlayer(id)
+			log.Infof("Player %s evicted, not seen since %s\n", player.Username, player.LastSeen.Format(time.RFC822Z))
+		}
+	}
+}
+
+// translate returns msg translated to locale, with its parameters also translated and filled in
+func translate(locale, msg string, params []string) string {
+	msg = lang.FormatString(locale, msg)
+	translated := make([]string, len(params))
+	for i, v := range params {
+		translated[i] = lang.GetString(locale, v)
+	}
+	return sprintStrings(msg, translated)
+}
+
+var formatVerb = regexp.MustCompile(`%%|%(\[(\d+)\])?[a-zA-Z]|%`)
+
+// sprintStrings formats args with format without the fmt error markers. Every verb prints a string,
+// missing arguments print nothing, extra ones are dropped and a % that isn't a verb is kept as is.
+func sprintStrings(format string, args []string) string {
+	last, count := 0, 0
+	format = formatVerb.ReplaceAllStringFunc(format, func(verb string) string {
+		if verb == "%%" {
+			return verb
+		}
+		m := formatVerb.FindStringSubmatch(verb)
+		if len(verb) == 1 {
+			return "%%"
+		}
+		n := last + 1
+		if m[2] != "" {
+			n, _ = strconv.Atoi(m[2])
+		}
+		if n < 1 {
+			return "%" + verb
+		}
+		last = n
+		if n > count {
+			count = n
+		}
+		return verb[:len(verb)-1] + "s"
+	})
+	values := make([]any, count)
+	for i := range values {
+		values[i] = ""
+		if i < len(args) {
+			values[i] = args[i]
+		}
+	}
+	return fmt.Sprintf(format, values...)
+}
+
+// translateText returns the message of txt translated to locale, filling in its parameters.
+func translateText(locale string, txt *packet.Text) string {
+	if !txt.NeedsTranslation {
+		return txt.Message
+	}
+	return translate(locale, txt.Message, txt.Parameters)
+}
+
+// applyMoveDelta returns pos with the axes present in mv replaced. Despite the packet name, the
+// values sent are absolute, only the unchanged axes are left out.
+func applyMoveDelta(pos mgl32.Vec3, mv *packet.MoveActorDelta) mgl32.Vec3 {
+	if mv.Flags&packet.MoveActorDeltaFlagHasX != 0 {
+		pos[0] = mv.Position[0]
+	}
+	if mv.Flags&packet.MoveActorDeltaFlagHasY != 0 {
+		pos[1] = mv.Position[1]
+	}
+	if mv.Flags&packet.MoveActorDeltaFlagHasZ != 0 {
+		pos[2] = mv.Position[2]
+	}
+	return pos
+}
+
+func handlePacket(conn ServerConn, pk packet.Packet) {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	if pk != nil {
+		switch pk.ID() {
+		case packet.IDText:
+			txt := pk.(*packet.Text)
+			if cfg.IsPlayerIgnored(txt.SourceName) {
+				log.Debugf("Ignoring message from %s\n", txt.SourceName)
+			} else if txt.TextType != packet.TextTypeObjectWhisper {
+				translated := translateText("ptbr", txt)
+				if txt.NeedsTranslation {
+					log.Debugf("Translated %q %q to %q\n", txt.Message, txt.Parameters, translated)
+				}
+				msg := formatChat(translated)
+				log.Infof("%s> %s\n", txt.SourceName, msg)
+				if txt.TextType == packet.TextTypeChat {
+					noteActivity()
+					recordChat(txt.SourceName, lang.StripFormatting(txt.Message))
+					writeTranscript(txt.SourceName, lang.StripFormatting(translated), txt.NeedsTranslation)
+					handleCommand(conn, txt.SourceName, txt.Message, cfg.Commands.ChatPrefix)
+				} else if txt.TextType == packet.TextTypeWhisper {
+					noteActivity()
+					handleCommand(conn, txt.SourceName, txt.Message, cfg.Commands.WhisperPrefix)
+				} else {
+					plain := lang.StripFormatting(translated)
+					handleLoginMessage(conn, plain)
+					handlePartyInvite(conn, plain)
+					handleReconnectTrigger(plain)
+					handleMessageRules(conn, plain)
+					if matchesGameEnd(plain) {
+						onGameEnd(plain)
+					}
+				}
+			}
+
+		case packet.IDLevelChunk:
+			if behaviors.ReadyAt.IsZero() {
+				log.Infof("World loaded\n")
+				behaviors.ReadyAt = time.Now()
+				setState(StatePlaying)
+			}
+
+		case packet.IDChangeDimension:
+			cd := pk.(*packet.ChangeDimension)
+			log.Infof("Changing to dimension %d at %v\n", cd.Dimension, cd.Position)
+			dimension = cd.Dimension
+			selfPosition = cd.Position
+			// Entities from the old dimension won't be removed by the server
+			resetPlayers()
+			droppedItems = map[uint64]*DroppedItem{}
+			entities = map[uint64]*Entity{}
+			err := writePacket(conn, &packet.PlayerAction{
+				EntityRuntimeID: selfRuntimeID,
+				ActionType:      protocol.PlayerActionDimensionChangeDone,
+			})
+			if err != nil {
+				log.Errorf("Error acknowledging dimension change: %s\n", err)
+			}
+
+		case packet.IDAdventureSettings:
+			settings := pk.(*packet.AdventureSettings)
+			if settings.PlayerUniqueID == conn.GameData().EntityUniqueID {
+				selfAbilities = abilitiesFromSettings(settings)
+				log.Infof("Abilities updated: %+v\n", selfAbilities)
+			}
+
+		case packet.IDSetSpawnPosition:
+			sp := pk.(*packet.SetSpawnPosition)
+			if sp.SpawnType == packet.SpawnTypeWorld {
+				// Since 1.16 Position is the player's, the world spawn is in SpawnPosition
+				worldSpawn = sp.SpawnPosition
+				if worldSpawn.Y() == math.MinInt32 {
+					worldSpawn = sp.Position
+				}
+				log.Infof("World spawn changed to %v\n", worldSpawn)
+			}
+
+		case packet.IDMobEffect:
+			effect := pk.(*packet.MobEffect)
+			if effect.EntityRuntimeID == selfRuntimeID {
+				applyMobEffect(effect)
+			}
+
+		case packet.IDSetActorData:
+			data := pk.(*packet.SetActorData)
+			if data.EntityRuntimeID == selfRuntimeID {
+				updateSelfFlags(data.EntityMetadata)
+			}
+
+		case packet.IDSetActorLink:
+			link := pk.(*packet.SetActorLink).EntityLink
+			if link.RiderEntityUniqueID == conn.GameData().EntityUniqueID {
+				if link.Type == protocol.EntityLinkRemove {
+					log.Infof("Left vehicle %d\n", link.RiddenEntityUniqueID)
+					selfVehicle = 0
+				} else {
+					log.Infof("Entered vehicle %d, movement behaviors are paused\n", link.RiddenEntityUniqueID)
+					selfVehicle = link.RiddenEntityUniqueID
+				}
+				behaviors.Riding = selfVehicle != 0
+			}
+
+		case packet.IDSetTitle:
+			title := pk.(*packet.SetTitle)
+			if title.ActionType == packet.TitleActionSetTitle || title.ActionType == packet.TitleActionSetSubtitle {
+				if text := lang.StripFormatting(title.Text); matchesGameEnd(text) {
+					onGameEnd(text)
+				}
+			}
+
+		case packet.IDBossEvent:
+			if pk.(*packet.BossEvent).EventType == packet.BossEventHide && cfg.Game.EndOnBossBarHide {
+				onGameEnd("boss bar hidden")
+			}
+
+		case packet.IDUpdateBlock:
+			update := pk.(*packet.UpdateBlock)
+			log.Debugf("Block at %v changed to %s\n", update.Position, blockName(update.NewBlockRuntimeID))
+
+		case packet.IDSetTime:
+			setWorldTime(int64(pk.(*packet.SetTime).Time))
+			addTPSSample(time.Now(), worldTime)
+
+		case packet.IDUpdateAttributes:
+			attrs := pk.(*packet.UpdateAttributes)
+			if attrs.EntityRuntimeID == selfRuntimeID {
+				for _, v := range attrs.Attributes {
+					selfAttributes[v.Name] = v
+				}
+			}
+
+		case packet.IDCommandOutput:
+			handleCommandOutput(pk.(*packet.CommandOutput))
+
+		case packet.IDAvailableCommands:
+			setServerCommands(pk.(*packet.AvailableCommands))
+
+		case packet.IDInventoryContent:
+			content := pk.(*packet.InventoryContent)
+			updateInventory(func() {
+				inventory[content.WindowID] = content.Content
+			})
+			onSyncInventoryContent(content.WindowID)
+
+		case packet.IDContainerOpen:
+			onSyncContainerOpen(conn, pk.(*packet.ContainerOpen))
+
+		case packet.IDInventorySlot:
+			slot := pk.(*packet.InventorySlot)
+			updateInventory(func() {
+				setInventorySlot(slot.WindowID, slot.Slot, slot.NewItem)
+			})
+
+		case packet.IDPlayerHotBar:
+			hotbar := pk.(*packet.PlayerHotBar)
+			if hotbar.WindowID == protocol.WindowIDInventory {
+				updateInventory(func() {
+					selectedSlot = hotbar.SelectedHotBarSlot
+				})
+			}
+
+		case packet.IDMobEquipment:
+			equipment := pk.(*packet.MobEquipment)
+			if equipment.EntityRuntimeID == selfRuntimeID && equipment.WindowID == protocol.WindowIDInventory {
+				updateInventory(func() {
+					selectedSlot = uint32(equipment.HotBarSlot)
+					setInventorySlot(protocol.WindowIDInventory, selectedSlot, equipment.NewItem)
+				})
+			}
+
+		case packet.IDModalFormRequest:
+			handleFormRequest(conn, pk.(*packet.ModalFormRequest))
+
+		case packet.IDPlayerList:
+			list := pk.(*packet.PlayerList)
+			log.Infof("Received player list with %d players\n", len(list.Entries))
+			joined := list.ActionType == packet.PlayerListActionAdd && playerListReceived
+			for _, v := range list.Entries {
+				log.Infof("User: %s EntityID: %d\n", v.Username, v.EntityUniqueID)
+				if joined {
+					fireNotifications(conn, v.Username, "is online")
+				}
+			}
+			if list.ActionType == packet.PlayerListActionAdd {
+				playerListReceived = true
+			}
+
+		case packet.IDAddActor:
+			add := pk.(*packet.AddActor)
+			entities[add.EntityRuntimeID] = &Entity{
+				EntityRuntimeID: add.EntityRuntimeID,
+				Type:            add.EntityType,
+				Category:        classifyEntity(add.EntityType),
+				Position:        add.Position,
+			}
+			log.Debugf("Entity %s (%s) added at %v\n", add.EntityType, classifyEntity(add.EntityType), add.Position)
+
+		case packet.IDAddItemActor:
+			add := pk.(*packet.AddItemActor)
+			droppedItems[add.EntityRuntimeID] = &DroppedItem{
+				EntityRuntimeID: add.EntityRuntimeID,
+				Name:            itemName(add.Item.Stack.NetworkID),
+				Count:           add.Item.Stack.Count,
+				Position:        add.Position,
+			}
+			log.Debugf("Item %s x%d dropped at %v\n", itemName(add.Item.Stack.NetworkID), add.Item.Stack.Count, add.Position)
+
+		case packet.IDRemoveEntity:
+			rement := pk.(*packet.RemoveEntity)
+			delete(droppedItems, rement.EntityNetworkID)
+			delete(entities, rement.EntityNetworkID)
+			player, ok := players[rement.EntityNetworkID]
+			if ok {
+				removePlayer(rement.EntityNetworkID)
+				log.Infof("Player %s went of range\n", player.Username)
+			}
+
+		case packet.IDAddPlayer:
+			addent := pk.(*packet.AddPlayer)
+			log.Infof("Player %s added to %v\n", addent.Username, addent.Position)
+			players[addent.EntityRuntimeID] = &Player{
+				Username:        addent.Username,
+				EntityRuntimeID: addent.EntityRuntimeID,
+				EntityGlobalID:  addent.EntityUniqueID,
+				Position:        addent.Position,
+				LastSeen:        time.Now(),
+			}
+			updateCombatRange(players[addent.EntityRuntimeID])
+			fireNotifications(conn, addent.Username, "is nearby")
+
+		case packet.IDActorEvent:
+			event := pk.(*packet.ActorEvent)
+			if event.EventType == packet.EventTypePlayerDied {
+				log.Debugf("Entity %d died\n", event.EntityRuntimeID)
+				player, ok := players[event.EntityRuntimeID]
+				if ok {
+					noteActivity()
+					log.Warnf("Player %s died\n", player.Username)
+					onPlayerDeath(player.Username)
+				}
+			}
+
+		case packet.IDMovePlayer:
+			mv := pk.(*packet.MovePlayer)
+			if mv.EntityRuntimeID == selfRuntimeID {
+				selfPosition = mv.Position
+				if mv.Mode == packet.MoveModeReset || mv.Mode == packet.MoveModeTeleport {
+					onPositionCorrection(time.Now())
+				}
+				for _, player := range players {
+					updateCombatRange(player)
+				}
+			}
+			player, ok := players[mv.EntityRuntimeID]
+			if ok {
+				player.LastSeen = time.Now()
+				if !player.Position.ApproxEqual(mv.Position) {
+					player.Position = mv.Position
+					updateCombatRange(player)
+					//log.Infof("Player %s at %s\n", player.Username, player.Position)
+				}
+			}
+
+		case packet.IDMoveActorAbsolute:
+			mv := pk.(*packet.MoveActorAbsolute)
+			if item, ok := droppedItems[mv.EntityRuntimeID]; ok {
+				item.Position = mv.Position
+			}
+			if entity, ok := entities[mv.EntityRuntimeID]; ok {
+				entity.Position = mv.Position
+			}
+			player, ok := players[mv.EntityRuntimeID]
+			if ok {
+				player.LastSeen = time.Now()
+				if !player.Position.ApproxEqual(mv.Position) {
+					player.Position = mv.Position
+					updateCombatRange(player)
+					//log.Infof("Player %s at %s\n", player.Username, player.Position)
+				}
+			}
+
+		case packet.IDMoveActorDelta:
+			mv := pk.(*packet.MoveActorDelta)
+			if item, ok := droppedItems[mv.EntityRuntimeID]; ok {
+				item.Position = applyMoveDelta(item.Position, mv)
+			}
+			if entity, ok := entities[mv.EntityRuntimeID]; ok {
+				entity.Position = applyMoveDelta(entity.Position, mv)
+			}
+			player, ok := players[mv.EntityRuntimeID]
+			if ok {
+				player.LastSeen = time.Now()
+				//old := player.Position
+				player.Position = applyMoveDelta(player.Position, mv)
+				updateCombatRange(player)
+				//if !player.Position.ApproxEqual(old) {
+				//	log.Infof("Player %s at %s\n", player.Username, player.Position)
+				//}
+			}
+		}
+	}
+}
+
+// loopRunning is 1 while the event loops of a session run, both loops read and write it
+var loopRunning int32
+
+func isLoopRunning() bool {
+	return atomic.LoadInt32(&loopRunning) == 1
+}
+
+// reconnectRequest asks the TX loop to close the session and reconnect. It holds at most one
+// pending request, extra requests are dropped.
+var reconnectRequest = make(chan struct{}, 1)
+
+// startTime and reconnects are the session stats reported by !uptime
+var startTime = time.Now()
+var reconnects int64
+
+var errReconnectRequested = errors.New("reconnect requested")
+var errReadTimeout = errors.New("read timeout")
+var errWriteTimeout = errors.New("write timeout")
+
+// lastPacket is the UnixNano time of the last packet read, checked by the TX loop watchdog
+var lastPacket int64
+
+// writeLock is held while writing, so the packets of a writeBatch aren't interleaved with others
+var writeLock sync.Mutex
+
+// writePacket writes pk to conn within the configured write timeout. On timeout the connection is
+// considered dead and closed, which ends the session and reconnects.
+func writePacket(conn ServerConn, pk packet.Packet) error {
+	return writeBatch(conn, pk)
+}
+
+// writeBatch writes pks in order, without packets from other goroutines in between. It stops at the
+// first error.
+func writeBatch(conn ServerConn, pks ...packet.Packet) error {
+	writeLock.Lock()
+	defer writeLock.Unlock()
+	for _, pk := range pks {
+		if err := writeWithTimeout(conn, pk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeWithTimeout(conn ServerConn, pk packet.Packet) error {
+	if cfg.Connection.WriteTimeout <= 0 {
+		return conn.WritePacket(pk)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.WritePacket(pk)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(cfg.Connection.WriteTimeout):
+		log.Errorf("Timed out writing %T, closing connection\n", pk)
+		// Closing unblocks the write. It is waited for so it can't land after the write lock is released.
+		_ = conn.Close()
+		<-done
+		return errWriteTimeout
+	}
+}
+
+// endSession closes conn from the TX loop, reporting err as the reason the session ended
+func endSession(conn ServerConn, end chan<- error, err error) {
+	atomic.StoreInt32(&loopRunning, 0)
+	select {
+	case end <- err:
+	default:
+	}
+	_ = conn.Close()
+}
+
+func eventTxLoop(conn ServerConn, wg *sync.WaitGroup, stop chan struct{}, end chan<- error) {
+	defer wg.Done()
+	t := time.NewTicker(time.Second * 5)
+	defer t.Stop()
+	sweep := time.NewTicker(cfg.Players.SweepInterval)
+	defer sweep.Stop()
+	chat := time.NewTicker(cfg.Chat.Interval)
+	defer chat.Stop()
+	watchdog := time.NewTicker(time.Second)
+	defer watchdog.Stop()
+	// Packets written by behaviors are buffered by gophertunnel and flushed together every 50ms, so
+	// ticking faster than the server doesn't mean more writes
+	tick := time.NewTicker(time.Second / time.Duration(cfg.Behaviors.TickRate))
+	defer tick.Stop()
+	defer func() {
+		playersLock.Lock()
+		behaviors.StopAll()
+		playersLock.Unlock()
+	}()
+	log.Info("TX Event loop started\n")
+	// The tick ticker wakes the loop every tick, so it notices when the RX loop stops
+	for isLoopRunning() {
+		select {
+		case <-stop:
+			log.Infof("closing event loop\n")
+			atomic.StoreInt32(&loopRunning, 0)
+			_ = conn.Close()
+		case <-reconnectRequest:
+			log.Infof("Reconnect requested, closing connection\n")
+			endSession(conn, end, errReconnectRequested)
+		case <-watchdog.C:
+			since := time.Since(time.Unix(0, atomic.LoadInt64(&lastPacket)))
+			if cfg.Connection.ReadTimeout > 0 && since > cfg.Connection.ReadTimeout {
+				log.Errorf("No packets received for %s, closing connection\n", since.Round(time.Second))
+				endSession(conn, end, errReadTimeout)
+			}
+			playersLock.Lock()
+			updateLatencyPause(conn.Latency())
+			playersLock.Unlock()
+			checkIdle()
+		case <-tick.C:
+			playersLock.Lock()
+			behaviors.Tick(conn)
+			playersLock.Unlock()
+		case <-chat.C:
+			playersLock.Lock()
+			ready := !behaviors.ReadyAt.IsZero()
+			playersLock.Unlock()
+			if !ready {
+				// Hold the chat until the world is loaded
+				break
+			}
+			select {
+			case msg := <-chatQueue:
+				if staleChat(msg) {
+					log.Infof("Dropping stale queued message: %s\n", msg.Message)
+					break
+				}
+				writeChat(conn, msg.Message)
+			default:
+			}
+		case <-sweep.C:
+			if cfg.Players.StaleTTL > 0 {
+				evictStalePlayers(cfg.Players.StaleTTL)
+			}
+			//case <-t.C:
+			//	log.Infof("Sending message\n")
+			//	txt := &packet.Text{
+			//		Message:          text.Colourf("<B>The time now is</B>: <red>%s</red>", time.Now().Format(time.RFC822Z)),
+			//		SourceName:       "BOT",
+			//		NeedsTranslation: false,
+			//		TextType:         packet.TextTypeRaw,
+			//	}
+			//
+			//	if err := conn.WritePacket(txt); err != nil {
+			//		log.Errorf("Error sending message: %s\n", err)
+			//	}
+		}
+	}
+}
+
+// isConnectionLost tells whether err means the connection was closed or reset, as opposed to a
+// protocol error
+func isConnectionLost(err error) bool {
+	return errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+func eventRxLoop(conn ServerConn, wg *sync.WaitGroup, end chan<- error) {
+	defer wg.Done()
+	log.Info("RX Event loop started\n")
+	for {
+		pk, err := conn.ReadPacket()
+		atomic.StoreInt64(&lastPacket, time.Now().UnixNano())
+		if err != nil {
+			if disconnect, ok := errors.Unwrap(err).(minecraft.DisconnectError); ok {
+				log.Errorf("Disconnected: %s\n", lang.GetString("ptbr", disconnect.Error()))
+				end <- err
+			} else if isLoopRunning() && isConnectionLost(err) {
+				// The connection was not closed by the TX loop, so the server or the network dropped us
+				log.Errorf("Connection lost: %s\n", err)
+				end <- err
+			} else if isLoopRunning() {
+				// gophertunnel skips packets that fail to decode, so this is a broken stream
+				log.Errorf("Protocol error reading packet: %s\n", err)
+				end <- err
+			}
+			atomic.StoreInt32(&loopRunning, 0)
+			return
+		}
+		logPacket(pk)
+		handlePacket(conn, pk)
+	}
+}
+
+// dialServer opens a connection to the remote server. It is a variable so the reconnect loop can
+// run against a fake server.
+var dialServer = func(src oauth2.TokenSource) (ServerConn, error) {
+	// Packets that fail to decode are skipped by gophertunnel and reported here, as are the login
+	// failures that close the connection while dialing
+	errorLog := &dialLog{}
+	setState(StateAuthenticating)
+	dialer := minecraft.Dialer{
+		ErrorLog:          stdlog.New(errorLog, "", 0),
+		TokenSource:       src,
+		EnableClientCache: cfg.Connection.EnableClientCache,
+		PacketFunc:        logLoginPackets,
+	}
+	if cfg.Identity.Offline {
+		dialer.TokenSource = nil
+		dialer.IdentityData = login.IdentityData{
+			XUID:        cfg.Identity.XUID,
+			DisplayName: cfg.Identity.DisplayName,
+			Identity:    cfg.Identity.UUID,
+		}
+		// The XUID is cleared from offline logins unless asked to keep it
+		dialer.KeepXBLIdentityData = cfg.Identity.XUID != ""
+	}
+	conn, err := dialer.Dial("raknet", cfg.Connection.RemoteAddress)
+	if err != nil {
+		return nil, classifyDialError(err, errorLog.Last())
+	}
+	return conn, nil
+}
+
+// after is the clock used between connection attempts, time.After unless replaced
+var after = time.After
+
+// connect dials the remote server until it succeeds. It returns nil if stop is closed before that,
+// if the server kicked the bot while logging in for a reason that isn't retryable, or after
+// Reconnect.MaxAttempts failed attempts in a row.
+func connect(src oauth2.TokenSource, stop chan struct{}) ServerConn {
+	log.Infof("Connecting to %s\n", cfg.Connection.RemoteAddress)
+	for attempt := 1; ; attempt++ {
+		if cfg.Reconnect.MaxAttempts > 0 && attempt > cfg.Reconnect.MaxAttempts {
+			log.Errorf("Giving up after %d failed connection attempts\n", cfg.Reconnect.MaxAttempts)
+			return nil
+		}
+		setState(StateConnecting)
+		if cfg.Reconnect.CheckNetwork {
+			if err := checkNetwork(cfg.Connection.RemoteAddress); err != nil {
+				log.Errorf("Network seems down, retrying in %s: %s\n", cfg.Reconnect.NetworkDownDelay, err)
+				if !wait(stop, cfg.Reconnect.NetworkDownDelay) {
+					return nil
+				}
+				continue
+			}
+		}
+		conn, err := dialServer(src)
+		if err == nil {
+			return conn
+		}
+		var disconnect minecraft.DisconnectError
+		var dialErr *dialError
+		if errors.As(err, &disconnect) {
+			v := lang.GetString("ptbr", disconnect.Error())
+			log.Errorf("Disconnected: %s\n", v)
+			// Bans, whitelists and full servers kick while logging in, so the allowlist applies here too
+			if !cfg.IsRetryableReason(v) {
+				log.Errorf("Disconnect reason is not retryable, stopping\n")
+				return nil
+			}
+		} else if errors.As(err, &dialErr) {
+			log.Errorf("%s\n", dialErr)
+		} else {
+			log.Errorf("Server unreachable or refused the connection: %s\n", err)
+		}
+		if !wait(stop, time.Second) {
+			return nil
+		}
+	}
+}
+
+// tokenSource returns the Xbox Live token source from authProvider, or nil when connecting offline
+func tokenSource() (oauth2.TokenSource, error) {
+	if cfg.Identity.Offline {
+		log.Warnf("Connecting offline without Xbox Live authentication, this only works on servers that don't verify Xbox signatures\n")
+		return nil, nil
+	}
+	if cfg.Identity.XUID != "" || cfg.Identity.DisplayName != "" || cfg.Identity.UUID != "" {
+		log.Warnf("Identity overrides are ignored unless Identity.Offline is set\n")
+	}
+	return authProvider.Token()
+}
+
+// checkNetwork resolves addr and checks there is a route to it. Nothing is sent to the server.
+func checkNetwork(addr string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// wait sleeps for d. It returns false if stop is closed before that.
+func wait(stop chan struct{}, d time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-after(d):
+		return true
+	}
+}
+
+// runSession runs the event loops until the connection is closed. It returns the error that
+// ended the session, or nil if it was stopped.
+func runSession(conn ServerConn, stop chan struct{}) error {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	startSession(conn)
+	log.Infof("Connected to %s (version %s, difficulty %d, game mode %d)\n", formatChat(serverInfo.WorldName), serverInfo.GameVersion, serverInfo.Difficulty, serverInfo.GameMode)
+	end := make(chan error, 1)
+	atomic.StoreInt64(&lastPacket, time.Now().UnixNano())
+	select {
+	case <-reconnectRequest:
+		// Requested before this session started, the reconnect already happened
+	default:
+	}
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+
+	// Set before starting the loops, so an early RX failure can't get overwritten by the TX loop
+	atomic.StoreInt32(&loopRunning, 1)
+	log.Info("Bot started and connected\n")
+	go eventRxLoop(conn, wg, end)
+	go eventTxLoop(conn, wg, stop, end)
+
+	wg.Wait()
+
+	select {
+	case err := <-end:
+		return err
+	default:
+		return nil
+	}
+}
+
+// startSession resets the state left by the previous session and takes the new one from the
+// game data of conn
+func startSession(conn ServerConn) {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	resetPlayers()
+	droppedItems = map[uint64]*DroppedItem{}
+	entities = map[uint64]*Entity{}
+	itemNames = map[int32]string{}
+	for _, v := range conn.GameData().Items {
+		itemNames[int32(v.RuntimeID)] = v.Name
+	}
+	selfRuntimeID = conn.GameData().EntityRuntimeID
+	selfPosition = conn.GameData().PlayerPosition
+	dimension = conn.GameData().Dimension
+	worldSpawn = conn.GameData().WorldSpawn
+	selfAttributes = map[string]protocol.Attribute{}
+	worldTime = conn.GameData().Time
+	tpsSamples = nil
+	selfAbilities = Abilities{}
+	inventory = map[uint32][]protocol.ItemInstance{}
+	selectedSlot = 0
+	inventorySync.deadline = time.Time{}
+	serverCommands = map[string]ServerCommand{}
+	loginSent = false
+	playerListReceived = false
+	behaviors.ReadyAt = time.Time{}
+	behaviors.Stagger = cfg.Behaviors.StaggerWindow
+	behaviors.MovementPaused = false
+	behaviors.PausedUntil = time.Time{}
+	positionCorrections = nil
+	selfVehicle = 0
+	behaviors.Riding = false
+	selfOnFire = false
+	selfEffects = map[int32]Effect{}
+	serverInfo = ServerInfo{
+		WorldName:   conn.GameData().WorldName,
+		GameVersion: conn.GameData().BaseGameVersion,
+		Difficulty:  conn.GameData().Difficulty,
+		GameMode:    conn.GameData().PlayerGameMode,
+	}
+}
+
+// Run loads the config and runs the bot until it is stopped by a signal or can't reconnect
+func Run() {
+	log.Info("Loading configuration\n")
+	var err error
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		log.Fatalf("error loading config: %s\n", err)
+	}
+
+	if cfg.Log.File != "" {
+		if err := setupLogFile(); err != nil {
+			log.Fatalf("error opening log file: %s\n", err)
+		}
+	}
+
+	setPacketLog(cfg.Log.Packets)
+
+	checkAliases()
+
+	if cfg.Chat.PersistQueue {
+		if err := loadChatQueue(); err != nil {
+			log.Errorf("Error loading the chat queue: %s\n", err)
+		}
+	}
+
+	if err := lang.Check("ptbr"); err != nil {
+		log.Warnf("Messages won't be translated: %s\n", err)
+	}
+
+	switch cfg.Log.ChatFormat {
+	case "ansi":
+		plainChat = false
+	case "plain":
+		plainChat = true
+	default:
+		plainChat = !isTerminal(os.Stderr)
+	}
+
+	if cfg.World.BlockPalette != "" {
+		if err := loadBlockNames(cfg.World.BlockPalette); err != nil {
+			log.Warnf("Block names not loaded: %s\n", err)
+		}
+	}
+
+	if cfg.RPC.SocketPath != "" {
+		if err := serveRPC(cfg.RPC.SocketPath); err != nil {
+			log.Fatalf("error starting JSON-RPC: %s\n", err)
+		}
+	}
+
+	log.Info("Loading Xbox Token\n")
+	src, err := tokenSource()
+	if err != nil {
+		log.Fatalf("error getting token: %s\n", err)
+	}
+
+	c := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		log.Info("Closing bot\n")
+		close(stop)
+	}()
+
+	if cfg.Behaviors.StartupDelay > 0 {
+		log.Infof("Waiting %s before connecting\n", cfg.Behaviors.StartupDelay)
+		if !wait(stop, cfg.Behaviors.StartupDelay) {
+			log.Infoln("Gotcha. KTHXBYE")
+			return
+		}
+	}
+
+	for first := true; ; first = false {
+		conn := connect(src, stop)
+		if conn == nil {
+			break
+		}
+		if !first {
+			atomic.AddInt64(&reconnects, 1)
+		}
+		err := runSession(conn, stop)
+		noteActivity()
+		if err == nil {
+			break
+		}
+		if err == errReconnectRequested {
+			log.Infof("Reconnecting as requested\n")
+			if delay := time.Duration(atomic.SwapInt64(&reconnectDelay, 0)); delay > 0 {
+				log.Infof("Waiting %s before reconnecting\n", delay)
+				if !wait(stop, delay) {
+					break
+				}
+			}
+		} else if disconnect, ok := errors.Unwrap(err).(minecraft.DisconnectError); ok {
+			if !cfg.IsRetryableReason(lang.GetString("ptbr", disconnect.Error())) {
+				log.Errorf("Disconnect reason is not retryable, stopping\n")
+				break
+			}
+			log.Infof("Disconnect reason is retryable, reconnecting\n")
+		} else if isConnectionLost(err) || err == errReadTimeout || err == errWriteTimeout {
+			log.Infof("Connection lost, reconnecting\n")
+		} else {
+			log.Infof("Protocol error, reconnecting\n")
+		}
+		setState(StateReconnecting)
+		if !wait(stop, time.Second) {
+			break
+		}
+	}
+
+	if cfg.Chat.PersistQueue {
+		if err := saveChatQueue(); err != nil {
+			log.Errorf("Error saving the chat queue: %s\n", err)
+		}
+	}
+	setState(StateStopped)
+	log.Infoln("Gotcha. KTHXBYE")
+}
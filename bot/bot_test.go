@@ -0,0 +1,323 @@
+package bot
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// fakeDialer replaces dialServer and the clock for the duration of the test. The dials fail with
+// errs in order, then return conn. The waits between attempts return right away and are recorded.
+func fakeDialer(t *testing.T, conn ServerConn, errs ...error) (dials *int, waits *[]time.Duration) {
+	t.Helper()
+	dials, waits = new(int), new([]time.Duration)
+	oldDial, oldAfter := dialServer, after
+	t.Cleanup(func() { dialServer, after = oldDial, oldAfter })
+	dialServer = func(src oauth2.TokenSource) (ServerConn, error) {
+		*dials++
+		if *dials <= len(errs) {
+			return nil, errs[*dials-1]
+		}
+		return conn, nil
+	}
+	after = func(d time.Duration) <-chan time.Time {
+		*waits = append(*waits, d)
+		c := make(chan time.Time, 1)
+		c <- time.Time{}
+		return c
+	}
+	return dials, waits
+}
+
+func kick(reason string) error {
+	return &net.OpError{Op: "read", Err: minecraft.DisconnectError(reason)}
+}
+
+func TestConnectRetries(t *testing.T) {
+	setTestConfig(t, "")
+	conn := newFakeConn()
+	refused := errors.New("connection refused")
+	dials, waits := fakeDialer(t, conn, refused, refused, refused)
+
+	if got := connect(nil, make(chan struct{})); got != conn {
+		t.Fatalf("connect returned %v, want the fake conn", got)
+	}
+	if *dials != 4 {
+		t.Errorf("dialed %d times, want 4", *dials)
+	}
+	want := []time.Duration{time.Second, time.Second, time.Second}
+	if !reflect.DeepEqual(*waits, want) {
+		t.Errorf("waited %v between attempts, want %v", *waits, want)
+	}
+	if State() != StateConnecting {
+		t.Errorf("state is %s, want %s", State(), StateConnecting)
+	}
+}
+
+func TestConnectGivesUp(t *testing.T) {
+	setTestConfig(t, "[Reconnect]\nMaxAttempts = 3\n")
+	refused := errors.New("connection refused")
+	dials, waits := fakeDialer(t, newFakeConn(), refused, refused, refused, refused)
+
+	if got := connect(nil, make(chan struct{})); got != nil {
+		t.Fatalf("connect returned %v, want nil after 3 failures", got)
+	}
+	if *dials != 3 {
+		t.Errorf("dialed %d times, want 3", *dials)
+	}
+	if len(*waits) != 3 {
+		t.Errorf("waited %d times, want 3", len(*waits))
+	}
+}
+
+func TestConnectKicked(t *testing.T) {
+	setTestConfig(t, "[Reconnect]\nRetryableReasons = [\"restart\"]\n")
+
+	dials, _ := fakeDialer(t, newFakeConn(), kick("server restarting"))
+	if got := connect(nil, make(chan struct{})); got == nil || *dials != 2 {
+		t.Errorf("retryable kick: connect returned %v after %d dials, want the conn after 2", got, *dials)
+	}
+
+	dials, _ = fakeDialer(t, newFakeConn(), kick("You are banned"))
+	if got := connect(nil, make(chan struct{})); got != nil || *dials != 1 {
+		t.Errorf("other kick: connect returned %v after %d dials, want nil after 1", got, *dials)
+	}
+}
+
+func TestConnectStopped(t *testing.T) {
+	setTestConfig(t, "")
+	fakeDialer(t, newFakeConn(), errors.New("connection refused"))
+	after = func(d time.Duration) <-chan time.Time { return nil }
+	stop := make(chan struct{})
+	close(stop)
+	if got := connect(nil, stop); got != nil {
+		t.Errorf("connect returned %v after stop, want nil", got)
+	}
+}
+
+func TestRunSessionResetsState(t *testing.T) {
+	setTestConfig(t, "")
+	playersLock.Lock()
+	players[1] = &Player{Username: "Steve", EntityRuntimeID: 1}
+	entities[2] = &Entity{EntityRuntimeID: 2}
+	selfRuntimeID = 1
+	selfVehicle = 3
+	dimension = 1
+	playersLock.Unlock()
+
+	conn := newFakeConn()
+	conn.gameData = minecraft.GameData{
+		EntityRuntimeID: 10,
+		PlayerPosition:  mgl32.Vec3{1, 2, 3},
+		Dimension:       0,
+	}
+	close(conn.reads)
+	if err := runSession(conn, make(chan struct{})); !isConnectionLost(err) {
+		t.Fatalf("runSession returned %v, want a lost connection", err)
+	}
+
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	if len(players) != 0 || len(entities) != 0 {
+		t.Errorf("%d players and %d entities left from the previous session", len(players), len(entities))
+	}
+	if selfRuntimeID != 10 || selfPosition != conn.gameData.PlayerPosition || dimension != 0 || selfVehicle != 0 {
+		t.Errorf("bot state not taken from the new session: id %d at %v in dimension %d riding %d", selfRuntimeID, selfPosition, dimension, selfVehicle)
+	}
+}
+
+func TestTranslateText(t *testing.T) {
+	tests := []struct {
+		name string
+		txt  packet.Text
+		want string
+	}{
+		{
+			name: "no translation",
+			txt:  packet.Text{Message: "§aHello %s 100%"},
+			want: "§aHello %s 100%",
+		},
+		{
+			name: "parameters",
+			txt:  packet.Text{NeedsTranslation: true, Message: "%multiplayer.player.joined", Parameters: []string{"Steve"}},
+			want: "Steve entrou no jogo ",
+		},
+		{
+			name: "number parameters",
+			txt:  packet.Text{NeedsTranslation: true, Message: "%achievementScreen.hours", Parameters: []string{"3"}},
+			want: "3 horas  ",
+		},
+		{
+			name: "translated parameter",
+			txt:  packet.Text{NeedsTranslation: true, Message: "%chat.type.text", Parameters: []string{"Steve", "%item.diamond.name"}},
+			want: "<Steve> Diamante    ",
+		},
+		{
+			name: "missing parameter",
+			txt:  packet.Text{NeedsTranslation: true, Message: "%commands.tp.success", Parameters: []string{"Steve"}},
+			want: "Steve teletransportado(a) para   ",
+		},
+		{
+			name: "extra parameter",
+			txt:  packet.Text{NeedsTranslation: true, Message: "%multiplayer.player.joined", Parameters: []string{"Steve", "Alex"}},
+			want: "Steve entrou no jogo ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := translateText("ptbr", &test.txt); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSprintStrings(t *testing.T) {
+	tests := []struct {
+		format string
+		args   []string
+		want   string
+	}{
+		{"%s and %s", []string{"a", "b"}, "a and b"},
+		{"%[2]s before %[1]s", []string{"a", "b"}, "b before a"},
+		{"%[2]s then %s", []string{"a", "b", "c"}, "b then c"},
+		{"%d of %d", []string{"1", "2"}, "1 of 2"},
+		{"%s, %s", []string{"a"}, "a, "},
+		{"%s", []string{"a", "b"}, "a"},
+		{"100% done", nil, "100% done"},
+		{"100%% done", nil, "100% done"},
+		{"trailing %", nil, "trailing %"},
+		{"bad %[0]s index", []string{"a"}, "bad %[0]s index"},
+		{"bad %[x]s index", []string{"a"}, "bad %[x]s index"},
+	}
+	for _, test := range tests {
+		if got := sprintStrings(test.format, test.args); got != test.want {
+			t.Errorf("sprintStrings(%q, %q) = %q, want %q", test.format, test.args, got, test.want)
+		}
+	}
+}
+
+func TestUpdateAttributes(t *testing.T) {
+	conn := startTestSession(t)
+	handlePacket(conn, &packet.UpdateAttributes{
+		EntityRuntimeID: 1,
+		Attributes: []protocol.Attribute{
+			{Name: "minecraft:health", Value: 15, Max: 20},
+			{Name: "minecraft:player.level", Value: 3, Max: 24791},
+		},
+	})
+	// Attributes of other entities are ignored
+	handlePacket(conn, &packet.UpdateAttributes{
+		EntityRuntimeID: 2,
+		Attributes:      []protocol.Attribute{{Name: "minecraft:health", Value: 1, Max: 20}},
+	})
+	handlePacket(conn, &packet.UpdateAttributes{
+		EntityRuntimeID: 1,
+		Attributes:      []protocol.Attribute{{Name: "minecraft:health", Value: 18, Max: 20}},
+	})
+
+	if v := selfAttributes["minecraft:health"].Value; v != 18 {
+		t.Errorf("health is %g, want 18", v)
+	}
+	if v := selfAttributes["minecraft:player.level"].Value; v != 3 {
+		t.Errorf("level is %g, want 3 from the earlier update", v)
+	}
+}
+
+func TestSetActorLinkRiding(t *testing.T) {
+	conn := startTestSession(t)
+	handlePacket(conn, &packet.SetActorLink{EntityLink: protocol.EntityLink{
+		RiddenEntityUniqueID: 7,
+		RiderEntityUniqueID:  1,
+		Type:                 protocol.EntityLinkRider,
+	}})
+	if selfVehicle != 7 || !behaviors.Riding {
+		t.Errorf("riding %d (paused %v) after mounting, want 7", selfVehicle, behaviors.Riding)
+	}
+
+	// Links of other entities don't change the bot
+	handlePacket(conn, &packet.SetActorLink{EntityLink: protocol.EntityLink{
+		RiddenEntityUniqueID: 8,
+		RiderEntityUniqueID:  2,
+		Type:                 protocol.EntityLinkRemove,
+	}})
+	if selfVehicle != 7 {
+		t.Errorf("riding %d after another entity dismounted, want 7", selfVehicle)
+	}
+
+	handlePacket(conn, &packet.SetActorLink{EntityLink: protocol.EntityLink{
+		RiddenEntityUniqueID: 7,
+		RiderEntityUniqueID:  1,
+		Type:                 protocol.EntityLinkRemove,
+	}})
+	if selfVehicle != 0 || behaviors.Riding {
+		t.Errorf("riding %d (paused %v) after dismounting, want none", selfVehicle, behaviors.Riding)
+	}
+}
+
+// logCapture records the messages logged at or above a level
+type logCapture struct {
+	level    logrus.Level
+	messages []string
+}
+
+func (c *logCapture) Levels() []logrus.Level {
+	return logrus.AllLevels[:c.level+1]
+}
+
+func (c *logCapture) Fire(entry *logrus.Entry) error {
+	c.messages = append(c.messages, entry.Message)
+	return nil
+}
+
+// captureLog records what the bot logs at or above level for the duration of the test
+func captureLog(t *testing.T, level logrus.Level) *logCapture {
+	t.Helper()
+	c := &logCapture{level: level}
+	oldLevel, oldHooks := log.GetLevel(), log.ReplaceHooks(logrus.LevelHooks{})
+	log.SetLevel(level)
+	log.AddHook(c)
+	t.Cleanup(func() {
+		log.SetLevel(oldLevel)
+		log.ReplaceHooks(oldHooks)
+	})
+	return c
+}
+
+func TestTextDebugLog(t *testing.T) {
+	conn := startTestSession(t)
+	logs := captureLog(t, logrus.DebugLevel)
+	handlePacket(conn, &packet.Text{
+		TextType:         packet.TextTypeTranslation,
+		NeedsTranslation: true,
+		Message:          "%multiplayer.player.joined",
+		Parameters:       []string{"Steve"},
+	})
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "hello"})
+
+	want := []string{
+		"Translated \"%multiplayer.player.joined\" [\"Steve\"] to \"Steve entrou no jogo \"\n",
+		"> Steve entrou no jogo \n",
+		"Steve> hello\n",
+	}
+	var got []string
+	for _, v := range logs.messages {
+		if strings.HasPrefix(v, "Translated ") || strings.Contains(v, "> ") {
+			got = append(got, v)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+	drainChat()
+}
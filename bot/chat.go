@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+type queuedChat struct {
+	Message string
+	Queued  time.Time
+}
+
+const chatQueueKey = "chatqueue.json"
+
+// chatQueue holds messages waiting to be sent by the TX loop, one per configured chat interval
+var chatQueue = make(chan queuedChat, 16)
+
+// sendChat queues msg to the public chat. When the queue is full the oldest message is dropped.
+func sendChat(msg string) {
+	queueChat(queuedChat{Message: msg, Queued: time.Now()})
+}
+
+func queueChat(msg queuedChat) {
+	for {
+		select {
+		case chatQueue <- msg:
+			return
+		default:
+		}
+		select {
+		case old := <-chatQueue:
+			log.Warnf("Chat queue full, dropping message: %s\n", old.Message)
+		default:
+		}
+	}
+}
+
+// staleChat tells whether msg waited longer than Chat.MaxQueueAge and must not be sent anymore
+func staleChat(msg queuedChat) bool {
+	return cfg.Chat.MaxQueueAge > 0 && time.Since(msg.Queued) > cfg.Chat.MaxQueueAge
+}
+
+// saveChatQueue drains the queued messages to botStore, so they are sent after a restart
+func saveChatQueue() error {
+	var pending []queuedChat
+	for {
+		select {
+		case msg := <-chatQueue:
+			pending = append(pending, msg)
+			continue
+		default:
+		}
+		break
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	log.Infof("Saving %d queued chat messages\n", len(pending))
+	return botStore.Set(chatQueueKey, data)
+}
+
+// loadChatQueue queues the messages saved by saveChatQueue that are not stale, and clears them
+func loadChatQueue() error {
+	data, err := botStore.Get(chatQueueKey)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var pending []queuedChat
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return err
+	}
+	for _, v := range pending {
+		if staleChat(v) {
+			log.Infof("Dropping stale queued message: %s\n", v.Message)
+			continue
+		}
+		queueChat(v)
+	}
+	// Cleared so a crash doesn't send them twice
+	return botStore.Set(chatQueueKey, []byte("[]"))
+}
+
+func writeChat(conn ServerConn, msg string) {
+	id := conn.IdentityData()
+	err := writePacket(conn, &packet.Text{
+		TextType:   packet.TextTypeChat,
+		SourceName: id.DisplayName,
+		Message:    msg,
+		XUID:       id.XUID,
+	})
+	if err != nil {
+		log.Errorf("Error sending message: %s\n", err)
+	}
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadChatQueue(t *testing.T) {
+	setTestConfig(t, "[Chat]\nMaxQueueAge = \"5m\"")
+	s := useStore(t)
+	drainChat()
+
+	sendChat("first")
+	queueChat(queuedChat{Message: "stale", Queued: time.Now().Add(-10 * time.Minute)})
+	sendChat("second")
+	if err := saveChatQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drainChat(); len(got) != 0 {
+		t.Fatalf("%q still queued after saving", got)
+	}
+
+	if err := loadChatQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := drainChat(), []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %q, want %q without the stale message", got, want)
+	}
+	// The saved queue is cleared once loaded, so it is sent once
+	if data, _ := s.Get(chatQueueKey); string(data) != "[]" {
+		t.Errorf("saved queue is %s after loading, want it cleared", data)
+	}
+	if err := loadChatQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drainChat(); len(got) != 0 {
+		t.Errorf("loaded %q a second time", got)
+	}
+}
+
+func TestLoadChatQueueEmpty(t *testing.T) {
+	setTestConfig(t, "")
+	useStore(t)
+	drainChat()
+	if err := loadChatQueue(); err != nil {
+		t.Errorf("loading without a saved queue: %s", err)
+	}
+	if got := drainChat(); len(got) != 0 {
+		t.Errorf("loaded %q without a saved queue", got)
+	}
+}
@@ -0,0 +1,30 @@
+package bot
+
+import (
+	"strings"
+	"time"
+)
+
+type ChatMessage struct {
+	Time    time.Time
+	Message string
+}
+
+// playerChat holds the last Chat.PlayerLogSize messages of each player, by lowercase name. It is kept
+// across reconnects.
+var playerChat = map[string][]ChatMessage{}
+
+// recordChat adds msg to the chat log of the player named name, dropping the oldest message once the
+// log is full
+func recordChat(name, msg string) {
+	size := cfg.Chat.PlayerLogSize
+	if size <= 0 {
+		return
+	}
+	name = strings.ToLower(name)
+	messages := append(playerChat[name], ChatMessage{Time: time.Now(), Message: msg})
+	if len(messages) > size {
+		messages = append([]ChatMessage(nil), messages[len(messages)-size:]...)
+	}
+	playerChat[name] = messages
+}
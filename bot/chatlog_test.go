@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecordChat(t *testing.T) {
+	setTestConfig(t, "[Chat]\nPlayerLogSize = 3")
+	playerChat = map[string][]ChatMessage{}
+	t.Cleanup(func() { playerChat = map[string][]ChatMessage{} })
+
+	for i := 0; i < 5; i++ {
+		recordChat("Steve", fmt.Sprint("message ", i))
+	}
+	recordChat("alex", "hi")
+
+	messages := playerChat["steve"]
+	if len(messages) != 3 {
+		t.Fatalf("kept %d messages for Steve, want 3", len(messages))
+	}
+	for i, v := range messages {
+		if want := fmt.Sprint("message ", i+2); v.Message != want {
+			t.Errorf("message %d is %q, want %q", i, v.Message, want)
+		}
+	}
+	if cap(messages) > 3 {
+		t.Errorf("the chat log of Steve holds %d messages, want the dropped ones released", cap(messages))
+	}
+	if n := len(playerChat["alex"]); n != 1 {
+		t.Errorf("kept %d messages for alex, want 1", n)
+	}
+}
+
+func TestRecordChatDisabled(t *testing.T) {
+	setTestConfig(t, "[Chat]\nPlayerLogSize = 0")
+	playerChat = map[string][]ChatMessage{}
+	recordChat("Steve", "hello")
+	if len(playerChat) != 0 {
+		t.Errorf("recorded chat with the log disabled: %v", playerChat)
+	}
+}
@@ -0,0 +1,29 @@
+package bot
+
+// combatRangeEnters counts how many times a player entered the bot's melee reach
+var combatRangeEnters = 0
+
+// updateCombatRange fires the combat range events when player crosses the configured reach.
+// Leaving only happens past reach plus hysteresis, so a player standing at the edge doesn't flap.
+func updateCombatRange(player *Player) {
+	if cfg.IsPlayerIgnored(player.Username) {
+		return
+	}
+	dist := player.Position.Sub(selfPosition).Len()
+	if !player.InCombatRange && dist <= cfg.Combat.Reach {
+		player.InCombatRange = true
+		onCombatRangeEnter(player)
+	} else if player.InCombatRange && dist > cfg.Combat.Reach+cfg.Combat.Hysteresis {
+		player.InCombatRange = false
+		onCombatRangeExit(player)
+	}
+}
+
+func onCombatRangeEnter(player *Player) {
+	combatRangeEnters++
+	log.Warnf("Player %s entered combat range (%d so far)\n", player.Username, combatRangeEnters)
+}
+
+func onCombatRangeExit(player *Player) {
+	log.Infof("Player %s left combat range\n", player.Username)
+}
@@ -0,0 +1,512 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/google/uuid"
+)
+
+type command func(conn ServerConn, source string, args []string)
+
+var commands = map[string]command{
+	"here":      cmdHere,
+	"xp":        cmdXP,
+	"reconnect": cmdReconnect,
+	"say":       cmdSay,
+	"find":      cmdFind,
+	"tpto":      cmdTpTo,
+	"count":     cmdCount,
+	"uptime":    cmdUptime,
+	"debug":     cmdDebug,
+	"interact":  cmdInteract,
+	"packet":    cmdPacket,
+	"spawn":     cmdSpawn,
+	"lookat":    cmdLookAt,
+	"commands":  cmdCommands,
+	"notify":    cmdNotify,
+	"behavior":  cmdBehavior,
+	"behaviors": cmdBehaviors,
+	"cancel":    cmdCancel,
+	"tps":       cmdTPS,
+	"cmd":       cmdCmd,
+	"inv":       cmdInv,
+	"syncinv":   cmdSyncInv,
+	"mark":      cmdMark,
+	"waypoint":  cmdWaypoint,
+	"effects":   cmdEffects,
+}
+
+// resolveCommand returns the command named name, following the configured aliases. Commands take
+// precedence over aliases with the same name.
+func resolveCommand(name string) (command, bool) {
+	for i := 0; i <= len(cfg.Commands.Aliases); i++ {
+		if cmd, ok := commands[name]; ok {
+			return cmd, true
+		}
+		target, ok := cfg.Commands.Aliases[name]
+		if !ok {
+			return nil, false
+		}
+		name = strings.ToLower(target)
+	}
+	// More steps than aliases means a loop
+	return nil, false
+}
+
+// checkAliases warns about aliases that can't be used
+func checkAliases() {
+	for alias, target := range cfg.Commands.Aliases {
+		if _, ok := commands[alias]; ok {
+			log.Warnf("Alias %s has the name of a command and is ignored\n", alias)
+		} else if _, ok := resolveCommand(alias); !ok {
+			log.Warnf("Alias %s to %s doesn't lead to a command\n", alias, target)
+		}
+	}
+}
+
+// handleCommand runs msg if it is a command. Commands start with prefix and take space separated
+// arguments.
+func handleCommand(conn ServerConn, source, msg, prefix string) {
+	if source == conn.IdentityData().DisplayName || !strings.HasPrefix(msg, prefix) {
+		return
+	}
+	fields := strings.Fields(msg[len(prefix):])
+	if len(fields) == 0 {
+		return
+	}
+	cmd, ok := resolveCommand(strings.ToLower(fields[0]))
+	if !ok {
+		return
+	}
+	log.Infof("Player %s ran %s\n", source, msg)
+	defer func() {
+		// A broken command must not take the RX loop down
+		if err := recover(); err != nil {
+			log.Errorf("Command %s from %s panicked: %v\n%s", fields[0], source, err, debug.Stack())
+		}
+	}()
+	cmd(conn, source, fields[1:])
+}
+
+// formatPosition returns pos rounded to whole blocks as "x y z"
+func formatPosition(pos mgl32.Vec3) string {
+	return fmt.Sprintf("%d %d %d", int(math.Round(float64(pos.X()))), int(math.Round(float64(pos.Y()))), int(math.Round(float64(pos.Z()))))
+}
+
+// maxCoordinate is the farthest from 0 a coordinate can be, the world border of Bedrock worlds
+const maxCoordinate = 30000000
+
+// parseCoordinates parses x, y and z coordinates. A coordinate prefixed by ~ is relative to origin.
+// Coordinates must be finite and within maxCoordinate.
+func parseCoordinates(args []string, origin mgl32.Vec3) (mgl32.Vec3, error) {
+	var pos mgl32.Vec3
+	if len(args) != 3 {
+		return pos, fmt.Errorf("expected 3 coordinates, got %d", len(args))
+	}
+	for i, v := range args {
+		if strings.HasPrefix(v, "~") {
+			pos[i] = origin[i]
+			v = v[1:]
+			if v == "" {
+				continue
+			}
+		}
+		f, err := strconv.ParseFloat(v, 32)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return pos, fmt.Errorf("invalid coordinate %q", args[i])
+		}
+		pos[i] += float32(f)
+		if math.Abs(float64(pos[i])) > maxCoordinate {
+			return pos, fmt.Errorf("coordinate %q is out of the world", args[i])
+		}
+	}
+	return pos, nil
+}
+
+func cmdHere(conn ServerConn, source string, args []string) {
+	sendChat(renderTemplate(cfg.Chat.HereTemplate, source))
+}
+
+func cmdXP(conn ServerConn, source string, args []string) {
+	level := selfAttributes["minecraft:player.level"].Value
+	progress := selfAttributes["minecraft:player.experience"].Value
+	sendChat(fmt.Sprintf("Level %d (%d%% to next)", int(level), int(progress*100)))
+}
+
+func cmdReconnect(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	select {
+	case reconnectRequest <- struct{}{}:
+		// Replied right away, as queued messages would only go out after the reconnect
+		writeChat(conn, "Reconnecting")
+	default:
+		log.Infof("Reconnect already pending, ignoring request from %s\n", source)
+	}
+}
+
+// sanitizeSay makes msg safe to repeat in chat. Leading slashes are removed, so it can't run a
+// server command, and it is cut to maxLength runes.
+func sanitizeSay(msg string, maxLength int) string {
+	msg = strings.TrimSpace(strings.TrimLeftFunc(msg, func(r rune) bool {
+		return r == '/' || unicode.IsSpace(r)
+	}))
+	if r := []rune(msg); len(r) > maxLength {
+		msg = string(r[:maxLength])
+	}
+	return msg
+}
+
+func cmdSay(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	msg := sanitizeSay(strings.Join(args, " "), cfg.Chat.MaxLength)
+	if msg != "" {
+		sendChat(msg)
+	}
+}
+
+func cmdFind(conn ServerConn, source string, args []string) {
+	if len(args) != 1 {
+		sendChat("Usage: !find <player>")
+		return
+	}
+	player, live, ok := findPlayer(args[0])
+	pos := formatPosition(player.Position)
+	switch {
+	case !ok:
+		sendChat(fmt.Sprintf("I have never seen %s", args[0]))
+	case live:
+		sendChat(fmt.Sprintf("%s is at %s", player.Username, pos))
+	default:
+		sendChat(fmt.Sprintf("%s was last seen at %s, %s ago", player.Username, pos, time.Since(player.LastSeen).Round(time.Second)))
+	}
+}
+
+func cmdTpTo(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 1 {
+		sendChat("Usage: !tpto <player>")
+		return
+	}
+	line := fmt.Sprintf("tp %q %q", conn.IdentityData().DisplayName, args[0])
+	// The command output is read by the RX loop, which is running this command
+	go func() {
+		output, err := runCommand(conn, line, time.Second*5)
+		switch {
+		case err != nil:
+			log.Errorf("Error running /%s: %s\n", line, err)
+			sendChat(fmt.Sprintf("Could not teleport: %s", err))
+		case output.SuccessCount == 0:
+			sendChat(fmt.Sprintf("Could not teleport: %s", commandOutputText(output)))
+		default:
+			sendChat(commandOutputText(output))
+		}
+	}()
+}
+
+func cmdCount(conn ServerConn, source string, args []string) {
+	radius := cfg.Players.CountRadius
+	nearbyPlayers := 0
+	for _, v := range players {
+		if v.Position.Sub(selfPosition).Len() <= radius {
+			nearbyPlayers++
+		}
+	}
+	mobs := map[EntityCategory]int{}
+	for _, v := range entities {
+		if v.Position.Sub(selfPosition).Len() <= radius {
+			mobs[v.Category]++
+		}
+	}
+	sendChat(fmt.Sprintf("Within %d blocks: %d players, %d hostile, %d neutral, %d passive, %d items", int(radius), nearbyPlayers,
+		mobs[CategoryHostile], mobs[CategoryNeutral], mobs[CategoryPassive], len(nearbyItems(radius))))
+}
+
+// formatUptime returns d rounded to minutes, as in "1d2h13m"
+func formatUptime(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := d / (time.Hour * 24)
+	hours := d % (time.Hour * 24) / time.Hour
+	minutes := d % time.Hour / time.Minute
+	s := ""
+	if days > 0 {
+		s += fmt.Sprintf("%dd", days)
+	}
+	if days > 0 || hours > 0 {
+		s += fmt.Sprintf("%dh", hours)
+	}
+	return s + fmt.Sprintf("%dm", minutes)
+}
+
+func cmdUptime(conn ServerConn, source string, args []string) {
+	sendChat(fmt.Sprintf("Up for %s, reconnected %d times", formatUptime(time.Since(startTime)), atomic.LoadInt64(&reconnects)))
+}
+
+func cmdDebug(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 2 || args[0] != "packets" || (args[1] != "on" && args[1] != "off") {
+		sendChat("Usage: !debug packets on|off")
+		return
+	}
+	setPacketLog(args[1] == "on")
+	sendChat(fmt.Sprintf("Packet logging %s", args[1]))
+}
+
+func cmdInteract(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 1 {
+		sendChat("Usage: !interact <player>")
+		return
+	}
+	for id, v := range players {
+		if strings.EqualFold(v.Username, args[0]) {
+			if err := interactEntity(conn, id); err != nil {
+				sendChat(fmt.Sprintf("Could not interact with %s: %s", v.Username, err))
+			}
+			return
+		}
+	}
+	sendChat(fmt.Sprintf("%s is not in range", args[0]))
+}
+
+func cmdPacket(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 1 {
+		sendChat("Usage: !packet <type>")
+		return
+	}
+	id, ok := packetIDByName(args[0])
+	if !ok {
+		sendChat(fmt.Sprintf("Unknown packet type %s", args[0]))
+		return
+	}
+	capturePacket(id)
+	sendChat(fmt.Sprintf("Logging the next %s packet", args[0]))
+}
+
+func cmdSpawn(conn ServerConn, source string, args []string) {
+	spawn := mgl32.Vec3{float32(worldSpawn.X()), float32(worldSpawn.Y()), float32(worldSpawn.Z())}
+	sendChat(fmt.Sprintf("Spawn is at %s, %d blocks away", formatPosition(spawn), int(spawn.Sub(selfPosition).Len())))
+}
+
+func cmdLookAt(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	pos, err := parseCoordinates(args, selfPosition)
+	if err != nil {
+		sendChat(fmt.Sprintf("Usage: !lookat <x> <y> <z>: %s", err))
+		return
+	}
+	if err := lookAt(conn, pos); err != nil {
+		log.Errorf("Error looking at %v: %s\n", pos, err)
+	}
+}
+
+func cmdCommands(conn ServerConn, source string, args []string) {
+	var names []string
+	for name, v := range serverCommands {
+		// Aliases are listed with their command
+		if name == v.Name {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		sendChat("The server sent no commands")
+		return
+	}
+	sort.Strings(names)
+	msg := ""
+	for _, name := range names {
+		if msg != "" && len(msg)+len(name)+2 > cfg.Chat.MaxLength {
+			sendChat(msg)
+			msg = ""
+		}
+		if msg != "" {
+			msg += ", "
+		}
+		msg += name
+	}
+	sendChat(msg)
+}
+
+func cmdNotify(conn ServerConn, source string, args []string) {
+	if len(args) != 1 {
+		sendChat("Usage: !notify <player>")
+		return
+	}
+	addNotification(args[0], source)
+	sendChat(fmt.Sprintf("I'll tell you when %s shows up, %s", args[0], source))
+}
+
+func cmdBehavior(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		sendChat("Usage: !behavior <name> on|off")
+		return
+	}
+	if err := behaviors.SetEnabled(args[0], args[1] == "on"); err != nil {
+		sendChat(fmt.Sprintf("Can't toggle %s: %s", args[0], err))
+		return
+	}
+	sendChat(fmt.Sprintf("Behavior %s is %s", args[0], args[1]))
+}
+
+func cmdBehaviors(conn ServerConn, source string, args []string) {
+	var running []string
+	for _, v := range behaviors.Status() {
+		if v.Running {
+			running = append(running, fmt.Sprintf("%s (%d)", v.Name, v.Priority))
+		} else if v.SafeMode {
+			running = append(running, fmt.Sprintf("%s (safe mode)", v.Name))
+		}
+	}
+	if len(running) == 0 {
+		sendChat("No behavior is running")
+		return
+	}
+	sendChat("Running: " + strings.Join(running, ", "))
+}
+
+func cmdCancel(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 1 {
+		sendChat("Usage: !cancel <behavior>")
+		return
+	}
+	if err := behaviors.Cancel(args[0]); err != nil {
+		sendChat(fmt.Sprintf("Can't cancel %s: %s", args[0], err))
+		return
+	}
+	sendChat(fmt.Sprintf("Cancelled %s, !behavior %s on resumes it", args[0], args[0]))
+}
+
+func cmdTPS(conn ServerConn, source string, args []string) {
+	tps, ok := serverTPS()
+	if !ok {
+		sendChat("Can't tell the TPS yet, the server must send the world time a few times with the daylight cycle on")
+		return
+	}
+	sendChat(fmt.Sprintf("Server is running at %.1f TPS", tps))
+}
+
+func cmdCmd(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) == 0 {
+		sendChat("Usage: !cmd <command>")
+		return
+	}
+	line := strings.TrimPrefix(strings.Join(args, " "), "/")
+	// The command output is read by the RX loop, which is running this command
+	go func() {
+		outputs, err := streamCommand(conn, line, time.Second*3)
+		if err != nil {
+			sendChat(fmt.Sprintf("Could not run /%s: %s", line, err))
+			return
+		}
+		count := 0
+		for output := range outputs {
+			count++
+			sendChat(commandOutputText(output))
+		}
+		if count == 0 {
+			sendChat(fmt.Sprintf("No output from /%s", line))
+		}
+	}()
+}
+
+func cmdInv(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	slots := inventorySnapshot()
+	data, _ := json.Marshal(slots)
+	log.Infof("Inventory: %s\n", data)
+	msg := string(data)
+	if len(msg) > cfg.Chat.MaxLength {
+		msg = fmt.Sprintf("%d slots in use, too long for chat. The full inventory is in the log.", len(slots))
+	}
+	if err := sendCommand(conn, fmt.Sprintf("tell %q %s", source, msg), uuid.New()); err != nil {
+		log.Errorf("Error sending inventory: %s\n", err)
+	}
+}
+
+func cmdSyncInv(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if err := requestInventorySync(conn); err != nil {
+		log.Errorf("Error requesting inventory: %s\n", err)
+	}
+}
+
+func cmdMark(conn ServerConn, source string, args []string) {
+	if !cfg.IsUserAllowed(source) {
+		return
+	}
+	if len(args) != 1 {
+		sendChat("Usage: !mark <name>")
+		return
+	}
+	if err := markWaypoint(args[0]); err != nil {
+		log.Errorf("Error saving waypoint %s: %s\n", args[0], err)
+		sendChat(fmt.Sprintf("Could not save %s", args[0]))
+		return
+	}
+	sendChat(fmt.Sprintf("Marked %s at %s", args[0], formatPosition(selfPosition)))
+}
+
+func cmdWaypoint(conn ServerConn, source string, args []string) {
+	if len(args) != 1 {
+		sendChat("Usage: !waypoint <name>")
+		return
+	}
+	w, ok, err := findWaypoint(args[0])
+	switch {
+	case err != nil:
+		log.Errorf("Error loading waypoints: %s\n", err)
+		sendChat("Could not load the waypoints")
+	case !ok:
+		sendChat(fmt.Sprintf("There is no waypoint %s", args[0]))
+	case w.Dimension != dimension:
+		sendChat(fmt.Sprintf("%s is at %s in dimension %d", args[0], formatPosition(w.Position), w.Dimension))
+	default:
+		sendChat(fmt.Sprintf("%s is at %s, %d blocks away", args[0], formatPosition(w.Position), int(w.Position.Sub(selfPosition).Len())))
+	}
+}
+
+func cmdEffects(conn ServerConn, source string, args []string) {
+	effects := describeEffects()
+	if effects == "" {
+		sendChat("No effects")
+		return
+	}
+	sendChat("Effects: " + effects)
+}
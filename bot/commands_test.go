@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0m"},
+		{29 * time.Second, "0m"},
+		{30 * time.Second, "1m"},
+		{59 * time.Minute, "59m"},
+		{time.Hour, "1h0m"},
+		{25*time.Hour + 3*time.Minute, "1d1h3m"},
+		{48 * time.Hour, "2d0h0m"},
+	}
+	for _, test := range tests {
+		if got := formatUptime(test.d); got != test.want {
+			t.Errorf("formatUptime(%s) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestSanitizeSay(t *testing.T) {
+	tests := []struct {
+		msg       string
+		maxLength int
+		want      string
+	}{
+		{"hello", 10, "hello"},
+		{"/op Steve", 20, "op Steve"},
+		{"//kill @e", 20, "kill @e"},
+		{"  / /gamemode 1", 20, "gamemode 1"},
+		{"   padded   ", 20, "padded"},
+		{"olá mundo", 3, "olá"},
+		{"日本語のメッセージ", 4, "日本語の"},
+		{"///", 10, ""},
+	}
+	for _, test := range tests {
+		if got := sanitizeSay(test.msg, test.maxLength); got != test.want {
+			t.Errorf("sanitizeSay(%q, %d) = %q, want %q", test.msg, test.maxLength, got, test.want)
+		}
+	}
+}
+
+func TestIgnoredPlayerCommands(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Chat]\nIgnoredPlayers = [\"Griefer\"]\n")
+	drainChat()
+
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "griefer", Message: "!uptime"})
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeWhisper, SourceName: "Griefer", Message: "uptime"})
+	if msgs := drainChat(); len(msgs) != 0 {
+		t.Errorf("ignored player got replies %q", msgs)
+	}
+
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "!uptime"})
+	if msgs := drainChat(); len(msgs) != 1 {
+		t.Errorf("got replies %q, want one", msgs)
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	origin := mgl32.Vec3{10, 64, -20}
+	tests := []struct {
+		args []string
+		want mgl32.Vec3
+	}{
+		{[]string{"1", "2", "3"}, mgl32.Vec3{1, 2, 3}},
+		{[]string{"-1.5", "70", "0"}, mgl32.Vec3{-1.5, 70, 0}},
+		{[]string{"~", "~", "~"}, origin},
+		{[]string{"~5", "~-4", "100"}, mgl32.Vec3{15, 60, 100}},
+	}
+	for _, test := range tests {
+		got, err := parseCoordinates(test.args, origin)
+		if err != nil {
+			t.Errorf("parseCoordinates(%q): %s", test.args, err)
+		} else if got != test.want {
+			t.Errorf("parseCoordinates(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"1", "2"},
+		{"1", "2", "3", "4"},
+		{"x", "2", "3"},
+		{"~x", "2", "3"},
+		{"NaN", "0", "0"},
+		{"0", "Inf", "0"},
+		{"0", "0", "-Inf"},
+		{"1e39", "0", "0"},
+		{"40000000", "0", "0"},
+		{"~29999999", "0", "0"},
+	} {
+		if pos, err := parseCoordinates(args, origin); err == nil {
+			t.Errorf("parseCoordinates(%q) = %v, want an error", args, pos)
+		}
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	setTestConfig(t, `
+[Commands.Aliases]
+C = "count"
+u = "up"
+up = "uptime"
+here = "count"
+loop1 = "loop2"
+loop2 = "loop1"
+self = "self"
+dead = "nothing"
+`)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"count", "count"},
+		{"c", "count"},
+		{"u", "uptime"},
+		// Commands win over aliases with their name
+		{"here", "here"},
+		{"loop1", ""},
+		{"self", ""},
+		{"dead", ""},
+		{"unknown", ""},
+	}
+	for _, test := range tests {
+		cmd, ok := resolveCommand(test.name)
+		if test.want == "" {
+			if ok {
+				t.Errorf("%s resolved to a command, want none", test.name)
+			}
+			continue
+		}
+		if !ok || reflect.ValueOf(cmd).Pointer() != reflect.ValueOf(commands[test.want]).Pointer() {
+			t.Errorf("%s didn't resolve to %s", test.name, test.want)
+		}
+	}
+}
+
+func TestAliasedCommand(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Commands.Aliases]\nUp = \"uptime\"\n")
+	drainChat()
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "!UP"})
+	if msgs := drainChat(); len(msgs) != 1 || !strings.HasPrefix(msgs[0], "Up for") {
+		t.Errorf("got replies %q, want the uptime", msgs)
+	}
+}
+
+func TestCommandPanic(t *testing.T) {
+	conn := startTestSession(t)
+	commands["broken"] = func(conn ServerConn, source string, args []string) {
+		panic("broken command")
+	}
+	t.Cleanup(func() { delete(commands, "broken") })
+	drainChat()
+
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "!broken"})
+	// The panic must not leave playersLock held
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "!uptime"})
+	if msgs := drainChat(); len(msgs) != 1 {
+		t.Errorf("got replies %q after the panic, want the uptime", msgs)
+	}
+}
+
+func TestCmdCount(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Players]\nCountRadius = 10.0")
+	selfPosition = mgl32.Vec3{100, 64, 100}
+	players[2] = &Player{Username: "Steve", Position: mgl32.Vec3{105, 64, 100}}
+	players[3] = &Player{Username: "Alex", Position: mgl32.Vec3{150, 64, 100}}
+	entities[4] = &Entity{Type: "minecraft:zombie", Category: CategoryHostile, Position: mgl32.Vec3{100, 64, 108}}
+	entities[5] = &Entity{Type: "minecraft:creeper", Category: CategoryHostile, Position: mgl32.Vec3{100, 70, 100}}
+	entities[6] = &Entity{Type: "minecraft:cow", Category: CategoryPassive, Position: mgl32.Vec3{96, 64, 97}}
+	entities[7] = &Entity{Type: "minecraft:wolf", Category: CategoryNeutral, Position: mgl32.Vec3{100, 64, 120}}
+	droppedItems[8] = &DroppedItem{Name: "minecraft:dirt", Count: 1, Position: mgl32.Vec3{101, 64, 101}}
+	t.Cleanup(func() {
+		players = map[uint64]*Player{}
+		entities = map[uint64]*Entity{}
+		droppedItems = map[uint64]*DroppedItem{}
+	})
+	drainChat()
+
+	cmdCount(conn, "Steve", nil)
+	want := "Within 10 blocks: 1 players, 2 hostile, 0 neutral, 1 passive, 1 items"
+	if got := drainChat(); len(got) != 1 || got[0] != want {
+		t.Errorf("!count sent %q, want %q", got, want)
+	}
+}
+
+func TestCommandPrefixes(t *testing.T) {
+	tests := []struct {
+		config  string
+		typ     byte
+		msg     string
+		handled bool
+	}{
+		{"", packet.TextTypeChat, "!count", true},
+		{"", packet.TextTypeChat, "count", false},
+		{"", packet.TextTypeWhisper, "count", true},
+		{"", packet.TextTypeWhisper, "!count", false},
+		{"[Commands]\nChatPrefix = \".\"\nWhisperPrefix = \"!\"", packet.TextTypeChat, ".count", true},
+		{"[Commands]\nChatPrefix = \".\"\nWhisperPrefix = \"!\"", packet.TextTypeChat, "!count", false},
+		{"[Commands]\nChatPrefix = \".\"\nWhisperPrefix = \"!\"", packet.TextTypeWhisper, "!count", true},
+		{"[Commands]\nChatPrefix = \".\"\nWhisperPrefix = \"!\"", packet.TextTypeWhisper, "count", false},
+	}
+	for _, test := range tests {
+		conn := startTestSession(t)
+		setTestConfig(t, test.config)
+		drainChat()
+		handlePacket(conn, &packet.Text{TextType: test.typ, SourceName: "Steve", Message: test.msg})
+		if handled := len(drainChat()) > 0; handled != test.handled {
+			t.Errorf("text type %d %q with config %q: handled %v, want %v", test.typ, test.msg, test.config, handled, test.handled)
+		}
+	}
+}
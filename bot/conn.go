@@ -0,0 +1,20 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// ServerConn is the connection to the server used by the bot. It is implemented by *minecraft.Conn,
+// and lets the session run against a fake server.
+type ServerConn interface {
+	ReadPacket() (packet.Packet, error)
+	WritePacket(pk packet.Packet) error
+	GameData() minecraft.GameData
+	IdentityData() login.IdentityData
+	Latency() time.Duration
+	Close() error
+}
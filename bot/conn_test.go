@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/racerxdl/minebot/config"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// fakeConn is a ServerConn that reads the packets sent to its reads channel and records the packets
+// written to it. Reads return io.EOF once reads is closed, and net.ErrClosed after Close.
+type fakeConn struct {
+	gameData minecraft.GameData
+	identity login.IdentityData
+	reads    chan packet.Packet
+	// stall blocks the writes until Close, like a connection that stopped draining. The stalled
+	// packets are still recorded once the write returns.
+	stall bool
+
+	lock      sync.Mutex
+	written   []packet.Packet
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		identity: login.IdentityData{DisplayName: "minebot"},
+		reads:    make(chan packet.Packet, 16),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (c *fakeConn) ReadPacket() (packet.Packet, error) {
+	select {
+	case pk, ok := <-c.reads:
+		if !ok {
+			return nil, io.EOF
+		}
+		return pk, nil
+	case <-c.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (c *fakeConn) WritePacket(pk packet.Packet) error {
+	if c.stall {
+		<-c.closed
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.written = append(c.written, pk)
+	if c.stall {
+		return net.ErrClosed
+	}
+	return nil
+}
+
+// Written returns the packets written so far
+func (c *fakeConn) Written() []packet.Packet {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]packet.Packet(nil), c.written...)
+}
+
+func (c *fakeConn) GameData() minecraft.GameData     { return c.gameData }
+func (c *fakeConn) IdentityData() login.IdentityData { return c.identity }
+func (c *fakeConn) Latency() time.Duration           { return 0 }
+
+func (c *fakeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+// setTestConfig loads data as the config for the duration of the test, with the defaults of
+// LoadConfigFrom filled in
+func setTestConfig(t *testing.T, data string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.LoadConfigFrom(path)
+	if err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+	old := cfg
+	cfg = c
+	t.Cleanup(func() { cfg = old })
+}
+
+// startTestSession loads an empty config and starts a session on a fake conn, whose bot has runtime
+// ID 1 and unique ID 1
+func startTestSession(t *testing.T) *fakeConn {
+	t.Helper()
+	setTestConfig(t, "")
+	conn := newFakeConn()
+	conn.gameData.EntityRuntimeID = 1
+	conn.gameData.EntityUniqueID = 1
+	startSession(conn)
+	return conn
+}
+
+// drainChat returns the messages waiting in the chat queue, emptying it
+func drainChat() []string {
+	var msgs []string
+	for {
+		select {
+		case msg := <-chatQueue:
+			msgs = append(msgs, msg.Message)
+		default:
+			return msgs
+		}
+	}
+}
+
+// writtenCommands returns the command lines written to conn
+func writtenCommands(conn *fakeConn) []string {
+	var commands []string
+	for _, pk := range conn.Written() {
+		if cmd, ok := pk.(*packet.CommandRequest); ok {
+			commands = append(commands, cmd.CommandLine)
+		}
+	}
+	return commands
+}
+
+func TestWriteBatchTimeout(t *testing.T) {
+	setTestConfig(t, "[Connection]\nWriteTimeout = \"20ms\"")
+	conn := newFakeConn()
+	conn.stall = true
+
+	err := writeBatch(conn, &packet.Text{Message: "first"}, &packet.Text{Message: "second"})
+	if err != errWriteTimeout {
+		t.Fatalf("writing to a stalled connection: %v, want %v", err, errWriteTimeout)
+	}
+	// The timed out write is over by the time writeBatch returns, and the rest of the batch is dropped
+	written := conn.Written()
+	if len(written) != 1 || written[0].(*packet.Text).Message != "first" {
+		t.Errorf("written %v, want only the stalled packet", written)
+	}
+}
+
+func TestWriteBatchConcurrent(t *testing.T) {
+	setTestConfig(t, "")
+	conn := newFakeConn()
+	const writers, batch = 8, 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pks := make([]packet.Packet, batch)
+			for j := range pks {
+				pks[j] = &packet.Text{SourceName: fmt.Sprint(i), Message: fmt.Sprint(j)}
+			}
+			if err := writeBatch(conn, pks...); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	written := conn.Written()
+	if len(written) != writers*batch {
+		t.Fatalf("%d packets written, want %d", len(written), writers*batch)
+	}
+	// Each batch is written in one piece, in order
+	for i := 0; i < len(written); i += batch {
+		source := written[i].(*packet.Text).SourceName
+		for j := 0; j < batch; j++ {
+			if txt := written[i+j].(*packet.Text); txt.SourceName != source || txt.Message != fmt.Sprint(j) {
+				t.Fatalf("packet %d is %s/%s, want %s/%d", i+j, txt.SourceName, txt.Message, source, j)
+			}
+		}
+	}
+}
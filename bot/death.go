@@ -0,0 +1,22 @@
+package bot
+
+import (
+	"strings"
+	"time"
+)
+
+// lastDeathMessage is when the last death message was sent about each player, by lowercase name
+var lastDeathMessage = map[string]time.Time{}
+
+// onPlayerDeath sends the death message about player, at most once per Chat.DeathCooldown
+func onPlayerDeath(player string) {
+	if cfg.Chat.DeathTemplate == "" || cfg.IsPlayerIgnored(player) {
+		return
+	}
+	name := strings.ToLower(player)
+	if time.Since(lastDeathMessage[name]) < cfg.Chat.DeathCooldown {
+		return
+	}
+	lastDeathMessage[name] = time.Now()
+	sendChat(renderTemplate(cfg.Chat.DeathTemplate, player))
+}
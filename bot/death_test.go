@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestDeathCooldown(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Chat]\nDeathTemplate = \"RIP {player}\"\nDeathCooldown = \"30s\"\nIgnoredPlayers = [\"Herobrine\"]")
+	lastDeathMessage = map[string]time.Time{}
+	t.Cleanup(func() {
+		lastDeathMessage = map[string]time.Time{}
+		players = map[uint64]*Player{}
+	})
+	players[2] = &Player{Username: "Steve", EntityRuntimeID: 2}
+	players[3] = &Player{Username: "Alex", EntityRuntimeID: 3}
+	players[4] = &Player{Username: "Herobrine", EntityRuntimeID: 4}
+	drainChat()
+
+	die := func(id uint64) {
+		handlePacket(conn, &packet.ActorEvent{EntityRuntimeID: id, EventType: packet.EventTypePlayerDied})
+	}
+	die(2)
+	die(2)
+	die(3)
+	die(4)
+	// Deaths of untracked entities are ignored
+	die(5)
+	if got, want := drainChat(), []string{"RIP Steve", "RIP Alex"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+
+	lastDeathMessage["steve"] = time.Now().Add(-30 * time.Second)
+	die(2)
+	die(3)
+	if got, want := drainChat(), []string{"RIP Steve"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q after Steve's cooldown, want %q", got, want)
+	}
+}
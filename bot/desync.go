@@ -0,0 +1,30 @@
+package bot
+
+import "time"
+
+// positionCorrections are the times the server moved the bot back, within the desync window
+var positionCorrections []time.Time
+
+// onPositionCorrection records a server correction of the bot position. Too many of them in a short
+// time mean the bot movement fights the server, usually its anti-cheat.
+func onPositionCorrection(at time.Time) {
+	if cfg.Behaviors.DesyncCount <= 0 {
+		return
+	}
+	kept := positionCorrections[:0]
+	for _, v := range positionCorrections {
+		if at.Sub(v) < cfg.Behaviors.DesyncWindow {
+			kept = append(kept, v)
+		}
+	}
+	positionCorrections = append(kept, at)
+	if len(positionCorrections) < cfg.Behaviors.DesyncCount {
+		return
+	}
+	log.Warnf("Movement desync: the server corrected the bot position %d times in %s\n", len(positionCorrections), cfg.Behaviors.DesyncWindow)
+	positionCorrections = nil
+	if cfg.Behaviors.DesyncPause {
+		log.Warnf("Pausing movement for %s to resync\n", cfg.Behaviors.DesyncWindow)
+		behaviors.PausedUntil = at.Add(cfg.Behaviors.DesyncWindow)
+	}
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPositionCorrections(t *testing.T) {
+	setTestConfig(t, "[Behaviors]\nDesyncCount = 3\nDesyncWindow = \"10s\"\nDesyncPause = true")
+	useScheduler(t, readyScheduler())
+	positionCorrections = nil
+	t.Cleanup(func() { positionCorrections = nil })
+	start := time.Now()
+
+	// Corrections spread over more than the window are not a desync
+	for i := 0; i < 5; i++ {
+		onPositionCorrection(start.Add(time.Duration(i) * 6 * time.Second))
+	}
+	if !behaviors.PausedUntil.IsZero() {
+		t.Fatalf("movement paused until %s for corrections 6s apart", behaviors.PausedUntil)
+	}
+	if n := len(positionCorrections); n != 2 {
+		t.Errorf("%d corrections kept, want only the 2 within the window", n)
+	}
+
+	at := start.Add(30 * time.Second)
+	onPositionCorrection(at)
+	if !behaviors.PausedUntil.IsZero() {
+		t.Fatal("movement paused with only 2 recent corrections")
+	}
+	at = at.Add(time.Second)
+	onPositionCorrection(at)
+	if want := at.Add(10 * time.Second); !behaviors.PausedUntil.Equal(want) {
+		t.Fatalf("movement paused until %s, want %s", behaviors.PausedUntil, want)
+	}
+	if len(positionCorrections) != 0 {
+		t.Errorf("%d corrections kept after a desync, want them cleared", len(positionCorrections))
+	}
+
+	// The count starts over after a desync
+	onPositionCorrection(at.Add(time.Second))
+	onPositionCorrection(at.Add(2 * time.Second))
+	if want := at.Add(10 * time.Second); !behaviors.PausedUntil.Equal(want) {
+		t.Errorf("movement paused until %s after 2 more corrections, want it unchanged", behaviors.PausedUntil)
+	}
+}
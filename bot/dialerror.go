@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// dialError is a connection failure that happened while authenticating or while negotiating the
+// protocol with the server, as opposed to the server being unreachable
+type dialError struct {
+	// Stage is "authentication" or "protocol negotiation"
+	Stage string
+	// Detail is what gophertunnel logged before giving up, if anything
+	Detail string
+	Err    error
+}
+
+func (e *dialError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%s failed: %s (%s)", e.Stage, e.Detail, e.Err)
+	}
+	return fmt.Sprintf("%s failed: %s", e.Stage, e.Err)
+}
+
+func (e *dialError) Unwrap() error {
+	return e.Err
+}
+
+// negotiationErrors are substrings of the errors gophertunnel reports when the server speaks another
+// protocol version or its packets can't be decompressed
+var negotiationErrors = []string{"outdated", "decompress", "flate", "batch", "packet header"}
+
+// classifyDialError tells authentication and protocol negotiation failures apart from the other dial
+// errors. last is the last error gophertunnel logged while dialing.
+func classifyDialError(err error, last string) error {
+	msg := err.Error()
+	if strings.Contains(msg, "token") || strings.Contains(msg, "auth chain") {
+		return &dialError{Stage: "authentication", Err: err}
+	}
+	for _, v := range negotiationErrors {
+		if strings.Contains(last, v) || strings.Contains(msg, v) {
+			return &dialError{Stage: "protocol negotiation", Detail: last, Err: err}
+		}
+	}
+	return err
+}
+
+// dialLog is the Dialer error log. It logs as warnings and remembers the last line.
+type dialLog struct {
+	lock sync.Mutex
+	last string
+}
+
+func (d *dialLog) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+	log.Warnf("%s\n", line)
+	d.lock.Lock()
+	d.last = line
+	d.lock.Unlock()
+	return len(p), nil
+}
+
+func (d *dialLog) Last() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.last
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// effectNames are the names of the effect IDs of packet.MobEffect
+var effectNames = map[int32]string{
+	packet.EffectSpeed:          "speed",
+	packet.EffectSlowness:       "slowness",
+	packet.EffectHaste:          "haste",
+	packet.EffectMiningFatigue:  "mining_fatigue",
+	packet.EffectStrength:       "strength",
+	packet.EffectInstantHealth:  "instant_health",
+	packet.EffectInstantDamage:  "instant_damage",
+	packet.EffectJumpBoost:      "jump_boost",
+	packet.EffectNausea:         "nausea",
+	packet.EffectRegeneration:   "regeneration",
+	packet.EffectResistance:     "resistance",
+	packet.EffectFireResistance: "fire_resistance",
+	packet.EffectWaterBreathing: "water_breathing",
+	packet.EffectInvisibility:   "invisibility",
+	packet.EffectBlindness:      "blindness",
+	packet.EffectNightVision:    "night_vision",
+	packet.EffectHunger:         "hunger",
+	packet.EffectWeakness:       "weakness",
+	packet.EffectPoison:         "poison",
+	packet.EffectWither:         "wither",
+	packet.EffectHealthBoost:    "health_boost",
+	packet.EffectAbsorption:     "absorption",
+	packet.EffectSaturation:     "saturation",
+	packet.EffectLevitation:     "levitation",
+	packet.EffectFatalPoison:    "fatal_poison",
+	packet.EffectConduitPower:   "conduit_power",
+}
+
+// Effect is a status effect active on the bot
+type Effect struct {
+	Type      int32
+	Amplifier int32
+	Expires   time.Time
+}
+
+func (e Effect) Name() string {
+	if name, ok := effectNames[e.Type]; ok {
+		return name
+	}
+	return fmt.Sprintf("effect_%d", e.Type)
+}
+
+// selfEffects are the effects active on the bot, by effect ID
+var selfEffects = map[int32]Effect{}
+
+// applyMobEffect updates selfEffects from a MobEffect about the bot
+func applyMobEffect(pk *packet.MobEffect) {
+	switch pk.Operation {
+	case packet.MobEffectAdd, packet.MobEffectModify:
+		e := Effect{
+			Type:      pk.EffectType,
+			Amplifier: pk.Amplifier,
+			// Durations are in ticks
+			Expires: time.Now().Add(time.Duration(pk.Duration) * time.Second / 20),
+		}
+		selfEffects[pk.EffectType] = e
+		log.Infof("Got effect %s %d for %s\n", e.Name(), e.Amplifier+1, time.Until(e.Expires).Round(time.Second))
+	case packet.MobEffectRemove:
+		if e, ok := selfEffects[pk.EffectType]; ok {
+			log.Infof("Effect %s is gone\n", e.Name())
+			delete(selfEffects, pk.EffectType)
+		}
+	}
+}
+
+// describeEffects lists the active effects with their level and remaining time, sorted by name
+func describeEffects() string {
+	var list []string
+	for _, e := range selfEffects {
+		remaining := time.Until(e.Expires)
+		if remaining < 0 {
+			remaining = 0
+		}
+		list = append(list, fmt.Sprintf("%s %d (%s)", e.Name(), e.Amplifier+1, remaining.Round(time.Second)))
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
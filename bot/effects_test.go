@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestApplyMobEffect(t *testing.T) {
+	selfEffects = map[int32]Effect{}
+	t.Cleanup(func() { selfEffects = map[int32]Effect{} })
+
+	applyMobEffect(&packet.MobEffect{Operation: packet.MobEffectAdd, EffectType: packet.EffectSpeed, Amplifier: 1, Duration: 600})
+	e, ok := selfEffects[packet.EffectSpeed]
+	if !ok {
+		t.Fatal("speed not added")
+	}
+	if remaining := time.Until(e.Expires); remaining < 29*time.Second || remaining > 30*time.Second {
+		t.Errorf("600 ticks expire in %s, want 30s", remaining)
+	}
+	if got := describeEffects(); got != "speed 2 (30s)" {
+		t.Errorf("described as %q", got)
+	}
+
+	applyMobEffect(&packet.MobEffect{Operation: packet.MobEffectModify, EffectType: packet.EffectSpeed, Amplifier: 0, Duration: 200})
+	if e := selfEffects[packet.EffectSpeed]; e.Amplifier != 0 {
+		t.Errorf("amplifier is %d after the change, want 0", e.Amplifier)
+	}
+
+	applyMobEffect(&packet.MobEffect{Operation: packet.MobEffectRemove, EffectType: packet.EffectSpeed})
+	if len(selfEffects) != 0 {
+		t.Errorf("effects left after the removal: %v", selfEffects)
+	}
+	// Removing an effect the bot doesn't have is a no-op
+	applyMobEffect(&packet.MobEffect{Operation: packet.MobEffectRemove, EffectType: packet.EffectPoison})
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type EntityCategory int
+
+const (
+	CategoryOther EntityCategory = iota
+	CategoryHostile
+	CategoryNeutral
+	CategoryPassive
+	CategoryItem
+	CategoryPlayer
+)
+
+func (c EntityCategory) String() string {
+	switch c {
+	case CategoryHostile:
+		return "hostile"
+	case CategoryNeutral:
+		return "neutral"
+	case CategoryPassive:
+		return "passive"
+	case CategoryItem:
+		return "item"
+	case CategoryPlayer:
+		return "player"
+	}
+	return "other"
+}
+
+// entityCategories classifies the Bedrock entity type identifiers. Types not listed are CategoryOther.
+var entityCategories = map[string]EntityCategory{
+	"minecraft:player": CategoryPlayer,
+	"minecraft:item":   CategoryItem,
+
+	"minecraft:blaze":              CategoryHostile,
+	"minecraft:cave_spider":        CategoryHostile,
+	"minecraft:creeper":            CategoryHostile,
+	"minecraft:drowned":            CategoryHostile,
+	"minecraft:elder_guardian":     CategoryHostile,
+	"minecraft:ender_dragon":       CategoryHostile,
+	"minecraft:endermite":          CategoryHostile,
+	"minecraft:evocation_illager":  CategoryHostile,
+	"minecraft:ghast":              CategoryHostile,
+	"minecraft:guardian":           CategoryHostile,
+	"minecraft:hoglin":             CategoryHostile,
+	"minecraft:husk":               CategoryHostile,
+	"minecraft:magma_cube":         CategoryHostile,
+	"minecraft:phantom":            CategoryHostile,
+	"minecraft:piglin_brute":       CategoryHostile,
+	"minecraft:pillager":           CategoryHostile,
+	"minecraft:ravager":            CategoryHostile,
+	"minecraft:shulker":            CategoryHostile,
+	"minecraft:silverfish":         CategoryHostile,
+	"minecraft:skeleton":           CategoryHostile,
+	"minecraft:slime":              CategoryHostile,
+	"minecraft:spider":             CategoryHostile,
+	"minecraft:stray":              CategoryHostile,
+	"minecraft:vex":                CategoryHostile,
+	"minecraft:vindicator":         CategoryHostile,
+	"minecraft:warden":             CategoryHostile,
+	"minecraft:witch":              CategoryHostile,
+	"minecraft:wither":             CategoryHostile,
+	"minecraft:wither_skeleton":    CategoryHostile,
+	"minecraft:zoglin":             CategoryHostile,
+	"minecraft:zombie":             CategoryHostile,
+	"minecraft:zombie_villager":    CategoryHostile,
+	"minecraft:zombie_villager_v2": CategoryHostile,
+
+	"minecraft:bee":           CategoryNeutral,
+	"minecraft:dolphin":       CategoryNeutral,
+	"minecraft:enderman":      CategoryNeutral,
+	"minecraft:goat":          CategoryNeutral,
+	"minecraft:iron_golem":    CategoryNeutral,
+	"minecraft:llama":         CategoryNeutral,
+	"minecraft:panda":         CategoryNeutral,
+	"minecraft:piglin":        CategoryNeutral,
+	"minecraft:polar_bear":    CategoryNeutral,
+	"minecraft:trader_llama":  CategoryNeutral,
+	"minecraft:wolf":          CategoryNeutral,
+	"minecraft:zombie_pigman": CategoryNeutral,
+
+	"minecraft:allay":            CategoryPassive,
+	"minecraft:axolotl":          CategoryPassive,
+	"minecraft:bat":              CategoryPassive,
+	"minecraft:cat":              CategoryPassive,
+	"minecraft:chicken":          CategoryPassive,
+	"minecraft:cod":              CategoryPassive,
+	"minecraft:cow":              CategoryPassive,
+	"minecraft:donkey":           CategoryPassive,
+	"minecraft:fox":              CategoryPassive,
+	"minecraft:frog":             CategoryPassive,
+	"minecraft:glow_squid":       CategoryPassive,
+	"minecraft:horse":            CategoryPassive,
+	"minecraft:mooshroom":        CategoryPassive,
+	"minecraft:mule":             CategoryPassive,
+	"minecraft:ocelot":           CategoryPassive,
+	"minecraft:parrot":           CategoryPassive,
+	"minecraft:pig":              CategoryPassive,
+	"minecraft:pufferfish":       CategoryPassive,
+	"minecraft:rabbit":           CategoryPassive,
+	"minecraft:salmon":           CategoryPassive,
+	"minecraft:sheep":            CategoryPassive,
+	"minecraft:skeleton_horse":   CategoryPassive,
+	"minecraft:snow_golem":       CategoryPassive,
+	"minecraft:squid":            CategoryPassive,
+	"minecraft:strider":          CategoryPassive,
+	"minecraft:tadpole":          CategoryPassive,
+	"minecraft:tropicalfish":     CategoryPassive,
+	"minecraft:turtle":           CategoryPassive,
+	"minecraft:villager":         CategoryPassive,
+	"minecraft:villager_v2":      CategoryPassive,
+	"minecraft:wandering_trader": CategoryPassive,
+}
+
+func classifyEntity(entityType string) EntityCategory {
+	return entityCategories[entityType]
+}
+
+type Entity struct {
+	EntityRuntimeID uint64
+	Type            string
+	Category        EntityCategory
+	Position        mgl32.Vec3
+}
+
+// entities are the non player, non item entities in range, keyed by runtime ID. Guarded by playersLock.
+var entities = map[uint64]*Entity{}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/racerxdl/minebot/config"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+type form struct {
+	Type    string          `json:"type"`
+	Title   string          `json:"title"`
+	Content json.RawMessage `json:"content"`
+}
+
+// formElement is an element of a custom form
+type formElement struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Default json.RawMessage `json:"default"`
+	Options []string        `json:"options"`
+	Steps   []string        `json:"steps"`
+	Min     float64         `json:"min"`
+	Max     float64         `json:"max"`
+}
+
+// elementValue parses value as the response to the element, or its default if value is empty
+func elementValue(e formElement, value string) (interface{}, error) {
+	if value == "" && len(e.Default) > 0 {
+		// The default is JSON, a string for inputs and a number or a boolean otherwise
+		var def interface{}
+		if err := json.Unmarshal(e.Default, &def); err != nil {
+			return nil, fmt.Errorf("invalid default %s: %w", e.Default, err)
+		}
+		if def != nil {
+			value = fmt.Sprint(def)
+		}
+	}
+	switch e.Type {
+	case "label":
+		return nil, nil
+	case "input":
+		return value, nil
+	case "toggle":
+		if value == "" {
+			return false, nil
+		}
+		return strconv.ParseBool(value)
+	case "slider":
+		if value == "" {
+			return e.Min, nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err == nil && (v < e.Min || v > e.Max) {
+			err = fmt.Errorf("%g is out of the %g-%g range", v, e.Min, e.Max)
+		}
+		return v, err
+	case "dropdown", "step_slider":
+		options := e.Options
+		if e.Type == "step_slider" {
+			options = e.Steps
+		}
+		if value == "" {
+			return 0, nil
+		}
+		v, err := strconv.Atoi(value)
+		if err == nil && (v < 0 || v >= len(options)) {
+			err = fmt.Errorf("option %d doesn't exist", v)
+		}
+		return v, err
+	}
+	return nil, fmt.Errorf("unknown element type %q", e.Type)
+}
+
+// customFormResponse builds the response to a custom form with elements from values, by index
+func customFormResponse(elements []formElement, values []string) ([]byte, error) {
+	resp := make([]interface{}, len(elements))
+	for i, e := range elements {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		v, err := elementValue(e, value)
+		if err != nil {
+			return nil, fmt.Errorf("element %d (%s %q): %w", i, e.Type, e.Text, err)
+		}
+		resp[i] = v
+	}
+	return json.Marshal(resp)
+}
+
+// handleFormRequest answers forms matching the configured auto responses. Menu forms get the
+// button index, modal forms get true for the first button and false for the second, and custom
+// forms get the configured values.
+func handleFormRequest(conn ServerConn, req *packet.ModalFormRequest) {
+	f := form{}
+	if err := json.Unmarshal(req.FormData, &f); err != nil {
+		log.Errorf("Error parsing form %d: %s\n", req.FormID, err)
+		return
+	}
+	// Custom forms carry a list of elements as content, so only menu and modal forms have text here
+	content := ""
+	_ = json.Unmarshal(f.Content, &content)
+
+	var data []byte
+	resp, ok := cfg.FormResponse(f.Title, content)
+	if inviter, invite := matchInvite(f.Title + "\n" + content); invite {
+		// Invites are only accepted from allowed players, whatever the auto responses say
+		ok = acceptInvite(inviter)
+		resp = config.FormResponse{Button: cfg.Party.FormButton}
+	}
+	switch {
+	case ok && f.Type == "form":
+		data, _ = json.Marshal(resp.Button)
+	case ok && f.Type == "modal":
+		data, _ = json.Marshal(resp.Button == 0)
+	case ok && f.Type == "custom_form":
+		var elements []formElement
+		err := json.Unmarshal(f.Content, &elements)
+		if err == nil {
+			data, err = customFormResponse(elements, resp.Values)
+		}
+		if err != nil {
+			log.Errorf("Can't auto respond form %q: %s\n", f.Title, err)
+			return
+		}
+	case cfg.Forms.DismissUnknown:
+		data = []byte("null")
+	default:
+		log.Infof("Received form %q with no auto response\n", f.Title)
+		return
+	}
+
+	log.Infof("Auto responding form %q with %s\n", f.Title, data)
+	err := writePacket(conn, &packet.ModalFormResponse{
+		FormID:       req.FormID,
+		ResponseData: data,
+	})
+	if err != nil {
+		log.Errorf("Error responding form: %s\n", err)
+	}
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+const testCustomForm = `{
+	"type": "custom_form",
+	"title": "Settings",
+	"content": [
+		{"type": "label", "text": "Pick your settings"},
+		{"type": "input", "text": "Nickname", "default": "Bot"},
+		{"type": "input", "text": "Motto", "default": "\"quoted\""},
+		{"type": "toggle", "text": "Music", "default": true},
+		{"type": "slider", "text": "Volume", "min": 0, "max": 10, "default": 5},
+		{"type": "dropdown", "text": "Team", "options": ["red", "blue"], "default": 1},
+		{"type": "step_slider", "text": "Speed", "steps": ["slow", "fast"]}
+	]
+}`
+
+func TestCustomFormResponse(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, `
+[[Forms.AutoResponses]]
+Match = "Settings"
+Values = ["", "\"minebot\"", "", "false", "7.5", "", "1"]
+`)
+	handlePacket(conn, &packet.ModalFormRequest{FormID: 3, FormData: []byte(testCustomForm)})
+
+	written := conn.Written()
+	if len(written) != 1 {
+		t.Fatalf("%d packets written, want a form response", len(written))
+	}
+	resp, ok := written[0].(*packet.ModalFormResponse)
+	if !ok || resp.FormID != 3 {
+		t.Fatalf("wrote %#v, want the response to form 3", written[0])
+	}
+	// Quotes typed in an input are kept, the defaults are decoded from JSON
+	want := `[null,"\"minebot\"","\"quoted\"",false,7.5,1,1]`
+	if string(resp.ResponseData) != want {
+		t.Errorf("responded %s, want %s", resp.ResponseData, want)
+	}
+}
+
+func TestCustomFormResponseInvalid(t *testing.T) {
+	elements := []formElement{{Type: "slider", Min: 0, Max: 10}, {Type: "dropdown", Options: []string{"red"}}}
+	for _, values := range [][]string{{"11"}, {"", "1"}, {"", "red"}} {
+		if data, err := customFormResponse(elements, values); err == nil {
+			t.Errorf("values %q gave %s, want an error", values, data)
+		}
+	}
+	if _, err := customFormResponse([]formElement{{Type: "color"}}, nil); err == nil {
+		t.Error("responded an unknown element type")
+	}
+}
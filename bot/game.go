@@ -0,0 +1,24 @@
+package bot
+
+import "time"
+
+// lastGameEnd is when the last game end was handled, game ends are debounced by Game.EndCooldown
+var lastGameEnd time.Time
+
+// matchesGameEnd tells whether msg matches one of the configured game end patterns or keys
+func matchesGameEnd(msg string) bool {
+	return matchesAny(cfg.Game.EndPatterns, cfg.Game.EndKeys, msg)
+}
+
+// onGameEnd sends the end of game message, once per game. Servers often signal the end several times,
+// with a title, a message and the boss bar going away.
+func onGameEnd(signal string) {
+	if time.Since(lastGameEnd) < cfg.Game.EndCooldown {
+		return
+	}
+	lastGameEnd = time.Now()
+	log.Infof("Game ended: %s\n", signal)
+	if cfg.Game.EndMessage != "" {
+		sendChat(renderTemplate(cfg.Game.EndMessage, ""))
+	}
+}
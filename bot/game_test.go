@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestGameEndKey(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, `
+[Game]
+EndKeys = ["%multiplayer.player.left"]
+EndMessage = "gg, {count} players"
+`)
+	lastGameEnd = time.Time{}
+	t.Cleanup(func() { lastGameEnd = time.Time{} })
+	drainChat()
+
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeSystem, Message: "Steve entrou no jogo"})
+	if got := drainChat(); len(got) != 0 {
+		t.Fatalf("a join ended the game: %q", got)
+	}
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeTranslation, NeedsTranslation: true, Message: "%multiplayer.player.left", Parameters: []string{"Steve"}})
+	if got := drainChat(); len(got) != 1 || got[0] != "gg, 0 players" {
+		t.Fatalf("chat after the translated end message = %q, want [gg, 0 players]", got)
+	}
+}
+
+func TestGameEndDebounce(t *testing.T) {
+	startTestSession(t)
+	setTestConfig(t, "[Game]\nEndMessage = \"gg\"\nEndCooldown = \"1m\"")
+	lastGameEnd = time.Time{}
+	t.Cleanup(func() { lastGameEnd = time.Time{} })
+	drainChat()
+
+	// A title, a message and the boss bar hiding announce the same end
+	onGameEnd("title")
+	onGameEnd("message")
+	onGameEnd("boss bar")
+	if got := drainChat(); len(got) != 1 {
+		t.Fatalf("sent %q for one game end, want gg once", got)
+	}
+
+	lastGameEnd = time.Now().Add(-time.Minute)
+	onGameEnd("title")
+	if got := drainChat(); len(got) != 1 {
+		t.Errorf("sent %q for the next game end, want gg", got)
+	}
+}
@@ -0,0 +1,32 @@
+package bot
+
+// Entity metadata key holding the entity flags, and the bit of the on fire flag. They are not defined
+// by this gophertunnel version. There is no flag for being in lava, it only shows as being on fire.
+const (
+	entityDataKeyFlags   = 0
+	entityDataFlagOnFire = 0
+)
+
+// selfOnFire tells whether the bot is burning, from its entity flags
+var selfOnFire bool
+
+// updateSelfFlags fires onHazard when the bot catches fire or stops burning
+func updateSelfFlags(metadata map[uint32]any) {
+	flags, ok := metadata[entityDataKeyFlags].(int64)
+	if !ok {
+		return
+	}
+	onFire := flags&(1<<entityDataFlagOnFire) != 0
+	if onFire != selfOnFire {
+		selfOnFire = onFire
+		onHazard("fire", onFire)
+	}
+}
+
+func onHazard(hazard string, active bool) {
+	if active {
+		log.Warnf("Bot is in danger: %s\n", hazard)
+	} else {
+		log.Infof("Bot is out of danger: %s\n", hazard)
+	}
+}
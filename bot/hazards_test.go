@@ -0,0 +1,22 @@
+package bot
+
+import "testing"
+
+func TestUpdateSelfFlags(t *testing.T) {
+	selfOnFire = false
+	t.Cleanup(func() { selfOnFire = false })
+
+	updateSelfFlags(map[uint32]any{entityDataKeyFlags: int64(1 << entityDataFlagOnFire)})
+	if !selfOnFire {
+		t.Error("not on fire with the on fire flag set")
+	}
+	// Metadata without the flags leaves the flag as is
+	updateSelfFlags(map[uint32]any{1: int16(300)})
+	if !selfOnFire {
+		t.Error("stopped burning on metadata without flags")
+	}
+	updateSelfFlags(map[uint32]any{entityDataKeyFlags: int64(1 << 5)})
+	if selfOnFire {
+		t.Error("still on fire with the on fire flag cleared")
+	}
+}
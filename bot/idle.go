@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// idle lowers the log level to warnings after Log.IdleAfter without significant events
+var idle struct {
+	lock         sync.Mutex
+	lastActivity time.Time
+	quiet        bool
+	level        logrus.Level
+}
+
+// noteActivity records a significant event, restoring the log level if it was lowered
+func noteActivity() {
+	idle.lock.Lock()
+	defer idle.lock.Unlock()
+	idle.lastActivity = time.Now()
+	if idle.quiet {
+		idle.quiet = false
+		log.SetLevel(idle.level)
+		log.Infof("Activity resumed, logging at %s level\n", idle.level)
+	}
+}
+
+// checkIdle lowers the log level once nothing significant happened for Log.IdleAfter
+func checkIdle() {
+	if cfg.Log.IdleAfter <= 0 {
+		return
+	}
+	idle.lock.Lock()
+	defer idle.lock.Unlock()
+	if idle.lastActivity.IsZero() {
+		idle.lastActivity = time.Now()
+	}
+	if idle.quiet || time.Since(idle.lastActivity) < cfg.Log.IdleAfter || !log.IsLevelEnabled(logrus.InfoLevel) {
+		return
+	}
+	log.Infof("Nothing happened for %s, only logging warnings\n", cfg.Log.IdleAfter)
+	idle.quiet = true
+	idle.level = log.GetLevel()
+	log.SetLevel(logrus.WarnLevel)
+}
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestTextActivity(t *testing.T) {
+	conn := startTestSession(t)
+	tests := []struct {
+		txt  packet.Text
+		want bool
+	}{
+		{packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "hello"}, true},
+		{packet.Text{TextType: packet.TextTypeWhisper, SourceName: "Steve", Message: "hello"}, true},
+		{packet.Text{TextType: packet.TextTypeTip, Message: "Coins: 10"}, false},
+		{packet.Text{TextType: packet.TextTypePopup, Message: "Coins: 10"}, false},
+		{packet.Text{TextType: packet.TextTypeSystem, Message: "Welcome"}, false},
+	}
+	for _, test := range tests {
+		idle.lock.Lock()
+		idle.lastActivity = time.Time{}
+		idle.lock.Unlock()
+		txt := test.txt
+		handlePacket(conn, &txt)
+		idle.lock.Lock()
+		got := !idle.lastActivity.IsZero()
+		idle.lock.Unlock()
+		if got != test.want {
+			t.Errorf("text type %d noted activity = %v, want %v", test.txt.TextType, got, test.want)
+		}
+	}
+	drainChat()
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// interactRange is how far in blocks an entity can be to be interacted with
+const interactRange = 6
+
+var errUnknownEntity = errors.New("unknown entity")
+var errOutOfRange = errors.New("entity out of range")
+
+// lookAngles returns the yaw and pitch in degrees to look from one position to another
+func lookAngles(from, to mgl32.Vec3) (yaw, pitch float32) {
+	d := to.Sub(from)
+	yaw = float32(math.Atan2(float64(-d.X()), float64(d.Z())) * 180 / math.Pi)
+	pitch = float32(-math.Atan2(float64(d.Y()), math.Hypot(float64(d.X()), float64(d.Z()))) * 180 / math.Pi)
+	return yaw, pitch
+}
+
+// lookAt turns the bot towards pos without moving it
+func lookAt(conn ServerConn, pos mgl32.Vec3) error {
+	return writePacket(conn, lookAtPacket(pos))
+}
+
+func lookAtPacket(pos mgl32.Vec3) *packet.MovePlayer {
+	yaw, pitch := lookAngles(selfPosition, pos)
+	return &packet.MovePlayer{
+		EntityRuntimeID: selfRuntimeID,
+		Position:        selfPosition,
+		Pitch:           pitch,
+		Yaw:             yaw,
+		HeadYaw:         yaw,
+		Mode:            packet.MoveModeNormal,
+		OnGround:        true,
+	}
+}
+
+// entityPosition returns the position of the tracked player or entity with runtime id
+func entityPosition(id uint64) (mgl32.Vec3, bool) {
+	if player, ok := players[id]; ok {
+		return player.Position, true
+	}
+	if entity, ok := entities[id]; ok {
+		return entity.Position, true
+	}
+	return mgl32.Vec3{}, false
+}
+
+// interactEntity faces the entity with runtime id and right clicks it with the held item
+func interactEntity(conn ServerConn, id uint64) error {
+	pos, ok := entityPosition(id)
+	if !ok {
+		return errUnknownEntity
+	}
+	if pos.Sub(selfPosition).Len() > interactRange {
+		return errOutOfRange
+	}
+	return writeBatch(conn, lookAtPacket(pos), &packet.InventoryTransaction{
+		TransactionData: &protocol.UseItemOnEntityTransactionData{
+			TargetEntityRuntimeID: id,
+			ActionType:            protocol.UseItemOnEntityActionInteract,
+			HotBarSlot:            int32(selectedSlot),
+			HeldItem:              heldItemInstance(),
+			Position:              selfPosition,
+		},
+	})
+}
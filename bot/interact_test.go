@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestLookAngles(t *testing.T) {
+	tests := []struct {
+		to         mgl32.Vec3
+		yaw, pitch float32
+	}{
+		{mgl32.Vec3{0, 0, 10}, 0, 0},
+		{mgl32.Vec3{-10, 0, 0}, 90, 0},
+		{mgl32.Vec3{10, 0, 0}, -90, 0},
+		{mgl32.Vec3{0, 0, -10}, 180, 0},
+		{mgl32.Vec3{0, 10, 10}, 0, -45},
+		{mgl32.Vec3{0, -10, 0}, 0, 90},
+	}
+	for _, test := range tests {
+		yaw, pitch := lookAngles(mgl32.Vec3{}, test.to)
+		// -180 and 180 are the same yaw
+		yawDiff := math.Mod(float64(yaw-test.yaw)+540, 360) - 180
+		if math.Abs(yawDiff) > 0.01 || math.Abs(float64(pitch-test.pitch)) > 0.01 {
+			t.Errorf("looking at %v: yaw %g pitch %g, want %g %g", test.to, yaw, pitch, test.yaw, test.pitch)
+		}
+	}
+}
+
+func TestInteractEntity(t *testing.T) {
+	conn := testItems(t)
+	selfPosition = mgl32.Vec3{0, 64, 0}
+	inventory[protocol.WindowIDInventory] = []protocol.ItemInstance{{}, stack(264, 1)}
+	selectedSlot = 1
+	entities[5] = &Entity{EntityRuntimeID: 5, Type: "minecraft:villager", Position: mgl32.Vec3{0, 64, 3}}
+	entities[6] = &Entity{EntityRuntimeID: 6, Type: "minecraft:villager", Position: mgl32.Vec3{0, 64, 30}}
+	t.Cleanup(func() { entities = map[uint64]*Entity{} })
+
+	if err := interactEntity(conn, 4); err != errUnknownEntity {
+		t.Errorf("interacting with an unknown entity: %v, want %v", err, errUnknownEntity)
+	}
+	if err := interactEntity(conn, 6); err != errOutOfRange {
+		t.Errorf("interacting with a far entity: %v, want %v", err, errOutOfRange)
+	}
+	if n := len(conn.Written()); n != 0 {
+		t.Fatalf("%d packets written for failed interactions", n)
+	}
+
+	if err := interactEntity(conn, 5); err != nil {
+		t.Fatal(err)
+	}
+	written := conn.Written()
+	if len(written) != 2 {
+		t.Fatalf("%d packets written, want a look and an interaction", len(written))
+	}
+	if look, ok := written[0].(*packet.MovePlayer); !ok || look.Yaw != 0 || look.Position != selfPosition {
+		t.Errorf("first packet is %#v, want the bot looking towards +Z", written[0])
+	}
+	transaction, ok := written[1].(*packet.InventoryTransaction)
+	if !ok {
+		t.Fatalf("second packet is %T, want an inventory transaction", written[1])
+	}
+	data, ok := transaction.TransactionData.(*protocol.UseItemOnEntityTransactionData)
+	if !ok {
+		t.Fatalf("transaction data is %T, want a use on entity", transaction.TransactionData)
+	}
+	if data.TargetEntityRuntimeID != 5 || data.ActionType != protocol.UseItemOnEntityActionInteract || data.HotBarSlot != 1 || data.HeldItem.Stack.NetworkID != 264 {
+		t.Errorf("transaction data is %+v, want slot 1 with a diamond interacting with entity 5", data)
+	}
+}
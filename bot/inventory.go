@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"sort"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// inventory holds the bot item slots per window, such as protocol.WindowIDInventory and
+// protocol.WindowIDArmour. Guarded by playersLock.
+var inventory = map[uint32][]protocol.ItemInstance{}
+
+// selectedSlot is the selected hotbar slot of the bot
+var selectedSlot uint32
+
+// stackName returns the item name of stack, handling empty slots
+func stackName(stack protocol.ItemStack) string {
+	if stack.NetworkID == 0 {
+		return "minecraft:air"
+	}
+	return itemName(stack.NetworkID)
+}
+
+func setInventorySlot(window, slot uint32, item protocol.ItemInstance) {
+	for uint32(len(inventory[window])) <= slot {
+		inventory[window] = append(inventory[window], protocol.ItemInstance{})
+	}
+	inventory[window][slot] = item
+}
+
+// heldItemInstance returns the item instance in the selected hotbar slot
+func heldItemInstance() protocol.ItemInstance {
+	slots := inventory[protocol.WindowIDInventory]
+	if selectedSlot >= uint32(len(slots)) {
+		return protocol.ItemInstance{}
+	}
+	return slots[selectedSlot]
+}
+
+// heldItem returns the item in the selected hotbar slot
+func heldItem() protocol.ItemStack {
+	return heldItemInstance().Stack
+}
+
+// updateInventory runs fn, which changes the inventory, firing onHeldItemChange if the held item changed
+func updateInventory(fn func()) {
+	before := heldItem()
+	fn()
+	after := heldItem()
+	if stackName(before) != stackName(after) || before.Count != after.Count {
+		onHeldItemChange(after)
+	}
+}
+
+func onHeldItemChange(stack protocol.ItemStack) {
+	log.Infof("Now holding %s x%d\n", stackName(stack), stack.Count)
+}
+
+// InventorySlot is a non empty inventory slot, as exported by !inv and the Inventory RPC
+type InventorySlot struct {
+	Window uint32 `json:"window"`
+	Slot   uint32 `json:"slot"`
+	Name   string `json:"name"`
+	Count  uint16 `json:"count"`
+}
+
+// inventorySnapshot lists the non empty slots of every window, sorted by window and slot
+func inventorySnapshot() []InventorySlot {
+	slots := []InventorySlot{}
+	for window, items := range inventory {
+		for i, v := range items {
+			if v.Stack.NetworkID == 0 {
+				continue
+			}
+			slots = append(slots, InventorySlot{
+				Window: window,
+				Slot:   uint32(i),
+				Name:   stackName(v.Stack),
+				Count:  v.Stack.Count,
+			})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if slots[i].Window != slots[j].Window {
+			return slots[i].Window < slots[j].Window
+		}
+		return slots[i].Slot < slots[j].Slot
+	})
+	return slots
+}
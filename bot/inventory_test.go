@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// testItems starts a session knowing a few items, to name the stacks
+func testItems(t *testing.T) *fakeConn {
+	t.Helper()
+	setTestConfig(t, "")
+	conn := newFakeConn()
+	conn.gameData.EntityRuntimeID = 1
+	conn.gameData.Items = []protocol.ItemEntry{
+		{Name: "minecraft:diamond", RuntimeID: 264},
+		{Name: "minecraft:dirt", RuntimeID: 3},
+	}
+	startSession(conn)
+	return conn
+}
+
+func stack(networkID int32, count uint16) protocol.ItemInstance {
+	return protocol.ItemInstance{Stack: protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: networkID}, Count: count}}
+}
+
+func TestHeldItem(t *testing.T) {
+	conn := testItems(t)
+	held := func() string {
+		return fmt.Sprintf("%s x%d", stackName(heldItem()), heldItem().Count)
+	}
+
+	handlePacket(conn, &packet.InventoryContent{
+		WindowID: protocol.WindowIDInventory,
+		Content:  []protocol.ItemInstance{stack(264, 2), stack(3, 64)},
+	})
+	if got := held(); got != "minecraft:diamond x2" {
+		t.Fatalf("holding %s after the inventory content, want the diamonds in slot 0", got)
+	}
+
+	handlePacket(conn, &packet.PlayerHotBar{WindowID: protocol.WindowIDInventory, SelectedHotBarSlot: 1})
+	if got := held(); got != "minecraft:dirt x64" {
+		t.Fatalf("holding %s after selecting slot 1, want the dirt", got)
+	}
+
+	// A change of the selected slot changes the held item
+	handlePacket(conn, &packet.InventorySlot{WindowID: protocol.WindowIDInventory, Slot: 1, NewItem: stack(3, 10)})
+	if got := held(); got != "minecraft:dirt x10" {
+		t.Fatalf("holding %s after the slot changed, want 10 dirt", got)
+	}
+	// Other slots don't
+	handlePacket(conn, &packet.InventorySlot{WindowID: protocol.WindowIDInventory, Slot: 0, NewItem: protocol.ItemInstance{}})
+	if got := held(); got != "minecraft:dirt x10" {
+		t.Fatalf("holding %s after another slot changed, want 10 dirt", got)
+	}
+
+	handlePacket(conn, &packet.MobEquipment{EntityRuntimeID: 2, WindowID: protocol.WindowIDInventory, HotBarSlot: 5, NewItem: stack(264, 1)})
+	if got := held(); got != "minecraft:dirt x10" {
+		t.Fatalf("holding %s after another player's equipment changed, want 10 dirt", got)
+	}
+	handlePacket(conn, &packet.MobEquipment{EntityRuntimeID: 1, WindowID: protocol.WindowIDInventory, HotBarSlot: 5, NewItem: stack(264, 1)})
+	if got := held(); got != "minecraft:diamond x1" {
+		t.Fatalf("holding %s after the bot equipment changed, want a diamond", got)
+	}
+	if n := len(inventory[protocol.WindowIDInventory]); n != 6 {
+		t.Errorf("inventory has %d slots, want it grown to 6", n)
+	}
+}
+
+func TestInventorySnapshot(t *testing.T) {
+	testItems(t)
+	inventory[protocol.WindowIDArmour] = []protocol.ItemInstance{{}, stack(3, 1)}
+	inventory[protocol.WindowIDInventory] = []protocol.ItemInstance{stack(264, 2), {}, stack(3, 64)}
+
+	data, err := json.Marshal(inventorySnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`[{"window":%d,"slot":0,"name":"minecraft:diamond","count":2},`+
+		`{"window":%[1]d,"slot":2,"name":"minecraft:dirt","count":64},`+
+		`{"window":%d,"slot":1,"name":"minecraft:dirt","count":1}]`, protocol.WindowIDInventory, protocol.WindowIDArmour)
+	if string(data) != want {
+		t.Errorf("inventory snapshot is %s, want %s", data, want)
+	}
+
+	inventory = map[uint32][]protocol.ItemInstance{}
+	if data, _ := json.Marshal(inventorySnapshot()); string(data) != "[]" {
+		t.Errorf("empty inventory snapshot is %s, want []", data)
+	}
+}
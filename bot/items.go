@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type DroppedItem struct {
+	EntityRuntimeID uint64
+	Name            string
+	Count           uint16
+	Position        mgl32.Vec3
+}
+
+// droppedItems are the item entities in range, keyed by runtime ID. Guarded by playersLock.
+var droppedItems = map[uint64]*DroppedItem{}
+
+// itemNames maps item network IDs to names, from the item list the server sent in StartGame
+var itemNames = map[int32]string{}
+
+func itemName(networkID int32) string {
+	if name, ok := itemNames[networkID]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown item %d", networkID)
+}
+
+// nearbyItems returns the dropped items within radius blocks of the bot
+func nearbyItems(radius float32) []DroppedItem {
+	var items []DroppedItem
+	for _, v := range droppedItems {
+		if v.Position.Sub(selfPosition).Len() <= radius {
+			items = append(items, *v)
+		}
+	}
+	return items
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestNearbyItems(t *testing.T) {
+	conn := newFakeConn()
+	conn.gameData.Items = []protocol.ItemEntry{{Name: "minecraft:diamond", RuntimeID: 264}}
+	setTestConfig(t, "")
+	startSession(conn)
+
+	handlePacket(conn, &packet.AddItemActor{
+		EntityRuntimeID: 5,
+		Item:            protocol.ItemInstance{Stack: protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: 264}, Count: 3}},
+		Position:        mgl32.Vec3{3, 0, 4},
+	})
+	handlePacket(conn, &packet.AddItemActor{
+		EntityRuntimeID: 6,
+		Item:            protocol.ItemInstance{Stack: protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: 1}, Count: 1}},
+		Position:        mgl32.Vec3{30, 0, 40},
+	})
+
+	items := nearbyItems(5)
+	if len(items) != 1 {
+		t.Fatalf("got %d items within 5 blocks, want 1", len(items))
+	}
+	if items[0].Name != "minecraft:diamond" || items[0].Count != 3 {
+		t.Errorf("got %+v, want 3 minecraft:diamond", items[0])
+	}
+	if n := len(nearbyItems(100)); n != 2 {
+		t.Errorf("got %d items within 100 blocks, want 2", n)
+	}
+
+	handlePacket(conn, &packet.RemoveEntity{EntityNetworkID: 5})
+	if n := len(nearbyItems(5)); n != 0 {
+		t.Errorf("got %d items after the pickup, want none", n)
+	}
+}
@@ -0,0 +1,19 @@
+package bot
+
+import "time"
+
+// updateLatencyPause pauses the movement behaviors when latency goes over the configured threshold and
+// resumes them once it is back under the resume threshold
+func updateLatencyPause(latency time.Duration) {
+	if cfg.Behaviors.PauseLatency <= 0 {
+		return
+	}
+	switch {
+	case !behaviors.MovementPaused && latency > cfg.Behaviors.PauseLatency:
+		log.Warnf("Latency is %s, pausing movement\n", latency.Round(time.Millisecond))
+		behaviors.MovementPaused = true
+	case behaviors.MovementPaused && latency < cfg.Behaviors.ResumeLatency:
+		log.Infof("Latency is back to %s, resuming movement\n", latency.Round(time.Millisecond))
+		behaviors.MovementPaused = false
+	}
+}
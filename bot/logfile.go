@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/racerxdl/minebot/lang"
+	"github.com/racerxdl/minebot/logfile"
+	"github.com/sirupsen/logrus"
+)
+
+// fileHook writes every log entry to a file without colours
+type fileHook struct {
+	w         io.Writer
+	formatter logrus.Formatter
+}
+
+func (h fileHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h fileHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write([]byte(lang.StripANSI(string(line))))
+	return err
+}
+
+// setupLogFile adds the configured log file to log
+func setupLogFile() error {
+	w, err := logfile.Open(cfg.Log.File, int64(cfg.Log.MaxSizeMB)<<20, cfg.Log.MaxBackups, cfg.Log.MaxAge)
+	if err != nil {
+		return err
+	}
+	log.AddHook(fileHook{
+		w:         w,
+		formatter: &logrus.TextFormatter{DisableColors: true, FullTimestamp: true},
+	})
+	if cfg.Log.FileOnly {
+		log.SetOutput(ioutil.Discard)
+	}
+	return nil
+}
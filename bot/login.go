@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// loginSent is set once the bot answered a login or register prompt in the current session
+var loginSent bool
+
+// loginFailed is set when the server rejected the password, and is kept across sessions so a wrong
+// password isn't retried on every reconnect
+var loginFailed bool
+
+// handleLoginMessage answers the server's /login and /register prompts with the configured password
+func handleLoginMessage(conn ServerConn, msg string) {
+	if loginFailed {
+		return
+	}
+	if loginSent && matchesLogin(cfg.Login.Failure, msg) {
+		log.Errorf("Login rejected by the server, not retrying: %s\n", msg)
+		loginFailed = true
+		return
+	}
+	if loginSent {
+		return
+	}
+
+	var line string
+	password := os.Getenv(cfg.Login.PasswordEnv)
+	switch {
+	case matchesLogin(cfg.Login.RegisterPrompt, msg):
+		log.Infof("Registering on the server\n")
+		line = "/register " + password + " " + password
+	case matchesLogin(cfg.Login.Prompt, msg):
+		log.Infof("Logging in on the server\n")
+		line = "/login " + password
+	default:
+		return
+	}
+	if password == "" {
+		log.Warnf("Server asked to log in but %s is not set\n", cfg.Login.PasswordEnv)
+		loginFailed = true
+		return
+	}
+	loginSent = true
+	// Never log line, it holds the password
+	if err := sendCommand(conn, line, uuid.New()); err != nil {
+		log.Errorf("Error sending login: %s\n", err)
+	}
+}
+
+func matchesLogin(pattern, msg string) bool {
+	if pattern == "" {
+		return false
+	}
+	re := cfg.Regexp(pattern)
+	return re != nil && re.MatchString(msg)
+}
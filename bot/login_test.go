@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandleLoginMessage(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, `
+[Login]
+Prompt = '^Use /login'
+RegisterPrompt = '^Use /register'
+Failure = 'Wrong password'
+PasswordEnv = "MINEBOT_TEST_PASSWORD"
+`)
+	t.Setenv("MINEBOT_TEST_PASSWORD", "hunter2")
+	loginFailed = false
+	t.Cleanup(func() { loginFailed = false })
+
+	handleLoginMessage(conn, "Welcome! Use /login <password>")
+	handleLoginMessage(conn, "Use /login <password>")
+	// Answered once per session
+	handleLoginMessage(conn, "Use /login <password>")
+	if got, want := writtenCommands(conn), []string{"/login hunter2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+
+	handleLoginMessage(conn, "Wrong password!")
+	if !loginFailed {
+		t.Error("the login failure wasn't recognized")
+	}
+}
+
+func TestHandleRegisterMessage(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Login]\nRegisterPrompt = '^Use /register'\nPasswordEnv = \"MINEBOT_TEST_PASSWORD\"")
+	t.Setenv("MINEBOT_TEST_PASSWORD", "hunter2")
+	handleLoginMessage(conn, "Use /register <password> <password>")
+	if got, want := writtenCommands(conn), []string{"/register hunter2 hunter2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// notifications maps lowercase player names to the players waiting to be told when they join or come
+// into range. Each one fires once.
+var notifications = map[string][]string{}
+
+// playerListReceived is set once the first player list of the session arrived. That one lists the
+// players already online when the bot joined, so it fires no notifications.
+var playerListReceived bool
+
+func addNotification(player, requester string) {
+	player = strings.ToLower(player)
+	for _, v := range notifications[player] {
+		if v == requester {
+			return
+		}
+	}
+	notifications[player] = append(notifications[player], requester)
+}
+
+// fireNotifications whispers the players waiting for player that it showed up, and forgets them
+func fireNotifications(conn ServerConn, player, event string) {
+	requesters := notifications[strings.ToLower(player)]
+	if len(requesters) == 0 || cfg.IsPlayerIgnored(player) {
+		return
+	}
+	delete(notifications, strings.ToLower(player))
+	for _, v := range requesters {
+		log.Infof("Telling %s that %s %s\n", v, player, event)
+		line := fmt.Sprintf("tell %q %s %s", v, player, event)
+		if err := sendCommand(conn, line, uuid.New()); err != nil {
+			log.Errorf("Error sending notification: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestJoinNotification(t *testing.T) {
+	conn := startTestSession(t)
+	notifications = map[string][]string{}
+	t.Cleanup(func() { notifications = map[string][]string{} })
+	cmdNotify(conn, "Alex", []string{"Steve"})
+	cmdNotify(conn, "Alex", []string{"steve"})
+	cmdNotify(conn, "Herobrine", []string{"Steve"})
+	drainChat()
+
+	join := &packet.PlayerList{
+		ActionType: packet.PlayerListActionAdd,
+		Entries:    []protocol.PlayerListEntry{{Username: "Notch"}, {Username: "Steve"}},
+	}
+	// The first list of the session holds the players already online
+	handlePacket(conn, join)
+	if got := writtenCommands(conn); len(got) != 0 {
+		t.Fatalf("sent %q for the players online when the bot joined", got)
+	}
+	handlePacket(conn, &packet.PlayerList{ActionType: packet.PlayerListActionRemove, Entries: join.Entries})
+	handlePacket(conn, join)
+	want := []string{`/tell "Alex" Steve is online`, `/tell "Herobrine" Steve is online`}
+	if got := writtenCommands(conn); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %q when Steve joined, want %q", got, want)
+	}
+
+	// Notifications fire once
+	handlePacket(conn, join)
+	handlePacket(conn, &packet.AddPlayer{Username: "Steve", EntityRuntimeID: 2})
+	if got := writtenCommands(conn); len(got) != 2 {
+		t.Errorf("sent %q, want no more notifications", got[2:])
+	}
+}
+
+func TestJoinNotificationIgnored(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Chat]\nIgnoredPlayers = [\"Herobrine\"]")
+	notifications = map[string][]string{}
+	t.Cleanup(func() { notifications = map[string][]string{} })
+	addNotification("Herobrine", "Alex")
+
+	handlePacket(conn, &packet.PlayerList{ActionType: packet.PlayerListActionAdd})
+	handlePacket(conn, &packet.PlayerList{
+		ActionType: packet.PlayerListActionAdd,
+		Entries:    []protocol.PlayerListEntry{{Username: "Herobrine"}},
+	})
+	handlePacket(conn, &packet.AddPlayer{Username: "Herobrine", EntityRuntimeID: 2})
+	if got := writtenCommands(conn); len(got) != 0 {
+		t.Errorf("sent %q for an ignored player", got)
+	}
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/racerxdl/minebot/config"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+var errObserving = errors.New("observed connections are not read by the bot")
+
+// Observer runs the packet handling of the bot on a connection it doesn't own, such as the one of a
+// proxied client. The packets the bot would write in response are dropped. The bot state is global,
+// so only one connection can be observed at a time.
+type Observer struct {
+	conn observedConn
+}
+
+// Observe starts observing the session described by gameData, played by identity, with the config c
+func Observe(c config.Config, gameData minecraft.GameData, identity login.IdentityData) *Observer {
+	cfg = c
+	o := &Observer{conn: observedConn{gameData: gameData, identity: identity}}
+	startSession(o.conn)
+	return o
+}
+
+// HandlePacket handles pk as if the bot had read it from the server
+func (o *Observer) HandlePacket(pk packet.Packet) {
+	handlePacket(o.conn, pk)
+}
+
+// observedConn is the ServerConn of an Observer. Nothing is read from it and writes are dropped.
+type observedConn struct {
+	gameData minecraft.GameData
+	identity login.IdentityData
+}
+
+func (c observedConn) ReadPacket() (packet.Packet, error) {
+	return nil, errObserving
+}
+
+func (c observedConn) WritePacket(pk packet.Packet) error {
+	log.Debugf("Observing only, dropped %T\n", pk)
+	return nil
+}
+
+func (c observedConn) GameData() minecraft.GameData     { return c.gameData }
+func (c observedConn) IdentityData() login.IdentityData { return c.identity }
+func (c observedConn) Latency() time.Duration           { return 0 }
+func (c observedConn) Close() error                     { return nil }
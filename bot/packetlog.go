@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// packetLog logs every packet received while enabled. It is toggled at runtime with !debug packets.
+// Single packets can be captured with !packet, capture holds the IDs waiting for one.
+var packetLog struct {
+	lock    sync.Mutex
+	enabled bool
+	capture map[uint32]bool
+}
+
+func setPacketLog(enabled bool) {
+	packetLog.lock.Lock()
+	defer packetLog.lock.Unlock()
+	packetLog.enabled = enabled
+}
+
+// capturePacket logs the next packet received with id
+func capturePacket(id uint32) {
+	packetLog.lock.Lock()
+	defer packetLog.lock.Unlock()
+	if packetLog.capture == nil {
+		packetLog.capture = map[uint32]bool{}
+	}
+	packetLog.capture[id] = true
+}
+
+// packetIDByName returns the ID of the packet type named name, such as "MovePlayer"
+func packetIDByName(name string) (uint32, bool) {
+	for id, pk := range packet.NewPool() {
+		if strings.EqualFold(reflect.TypeOf(pk()).Elem().Name(), name) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func logPacket(pk packet.Packet) {
+	packetLog.lock.Lock()
+	enabled := packetLog.enabled
+	captured := packetLog.capture[pk.ID()]
+	delete(packetLog.capture, pk.ID())
+	packetLog.lock.Unlock()
+	if enabled || captured {
+		log.Infof("Received %T: %+v\n", pk, pk)
+	}
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// logLoginPackets is a Dialer PacketFunc logging the network settings and resource packs the server
+// advertises, and noting when the bot starts spawning. The handshake is answered by gophertunnel itself
+// while dialing, so these packets never reach handlePacket.
+func logLoginPackets(header packet.Header, payload []byte, src, dst net.Addr) {
+	switch header.PacketID {
+	case packet.IDStartGame:
+		setState(StateSpawning)
+	case packet.IDNetworkSettings:
+		settings := &packet.NetworkSettings{}
+		if !decodePayload(settings, payload) {
+			return
+		}
+		log.Infof("Server compresses packets over %d bytes\n", settings.CompressionThreshold)
+	case packet.IDResourcePacksInfo:
+		info := &packet.ResourcePacksInfo{}
+		if !decodePayload(info, payload) {
+			return
+		}
+		log.Infof("Server has %d texture packs and %d behaviour packs (required: %t)\n", len(info.TexturePacks), len(info.BehaviourPacks), info.TexturePackRequired)
+		for _, v := range info.TexturePacks {
+			log.Infof("Texture pack %s version %s (%d bytes)\n", v.UUID, v.Version, v.Size)
+		}
+		for _, v := range info.BehaviourPacks {
+			log.Infof("Behaviour pack %s version %s (%d bytes)\n", v.UUID, v.Version, v.Size)
+		}
+	case packet.IDResourcePackStack:
+		stack := &packet.ResourcePackStack{}
+		if !decodePayload(stack, payload) {
+			return
+		}
+		log.Infof("Server resource pack stack has %d texture packs and %d behaviour packs\n", len(stack.TexturePacks), len(stack.BehaviourPacks))
+	}
+}
+
+// decodePayload unmarshals payload into pk, returning false if it is malformed
+func decodePayload(pk packet.Packet, payload []byte) (ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Warnf("Error decoding %T: %v\n", pk, err)
+			ok = false
+		}
+	}()
+	pk.Unmarshal(protocol.NewReader(bytes.NewBuffer(payload), 0))
+	return true
+}
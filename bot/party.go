@@ -0,0 +1,44 @@
+package bot
+
+import "github.com/google/uuid"
+
+// matchInvite tells whether msg is a party invite and returns the inviting player
+func matchInvite(msg string) (string, bool) {
+	if cfg.Party.InvitePattern == "" {
+		return "", false
+	}
+	re := cfg.Regexp(cfg.Party.InvitePattern)
+	if re == nil {
+		return "", false
+	}
+	m := re.FindStringSubmatch(msg)
+	if m == nil {
+		return "", false
+	}
+	if len(m) < 2 {
+		return "", true
+	}
+	return m[1], true
+}
+
+// acceptInvite tells whether an invite from inviter is accepted, and logs the decision
+func acceptInvite(inviter string) bool {
+	if !cfg.IsInviterAllowed(inviter) {
+		log.Infof("Ignoring party invite from %s\n", inviter)
+		return false
+	}
+	log.Infof("Accepting party invite from %s\n", inviter)
+	return true
+}
+
+// handlePartyInvite accepts party invites from allowed players received as server messages
+func handlePartyInvite(conn ServerConn, msg string) {
+	inviter, ok := matchInvite(msg)
+	if !ok || !acceptInvite(inviter) {
+		return
+	}
+	line := renderTemplate(cfg.Party.AcceptCommand, inviter)
+	if err := sendCommand(conn, line, uuid.New()); err != nil {
+		log.Errorf("Error accepting party invite: %s\n", err)
+	}
+}
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestHandlePartyInvite(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, `
+[Connection]
+AllowedNames = ["Steve"]
+
+[Party]
+InvitePattern = '^(\w+) invited you to a party$'
+AcceptCommand = "/party accept {player} {unknown}"
+`)
+	handlePartyInvite(conn, "Alex invited you to a party")
+	handlePartyInvite(conn, "Steve invited you to a party")
+	var commands []string
+	for _, pk := range conn.Written() {
+		if cmd, ok := pk.(*packet.CommandRequest); ok {
+			commands = append(commands, cmd.CommandLine)
+		}
+	}
+	if len(commands) != 1 || commands[0] != "/party accept Steve {unknown}" {
+		t.Errorf("commands sent = %q, want only the invite from Steve accepted", commands)
+	}
+}
+
+func TestMatchInviteInvalidPattern(t *testing.T) {
+	setTestConfig(t, "")
+	// Only a config that wasn't loaded by LoadConfigFrom can hold an invalid pattern
+	cfg.Party.InvitePattern = "(\\w+ invited you"
+	if inviter, ok := matchInvite("Steve invited you"); ok {
+		t.Errorf("matched an invite from %q with an invalid pattern", inviter)
+	}
+}
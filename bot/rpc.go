@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"strings"
+
+	"github.com/racerxdl/minebot/lang"
+)
+
+type Empty struct{}
+
+type Status struct {
+	Connected bool
+	State     string
+	WorldName string
+	Dimension int32
+	Position  [3]float32
+	Players   int
+	// Vehicle is the unique ID of the entity the bot rides, zero if none
+	Vehicle int64
+	OnFire  bool
+	// PendingAuth is the Xbox Live device code prompt while waiting for the login
+	PendingAuth string
+}
+
+// BotRPC is served as "Bot" over the JSON-RPC socket
+type BotRPC struct{}
+
+func (BotRPC) Status(args Empty, reply *Status) error {
+	pendingAuth.lock.Lock()
+	prompt := pendingAuth.prompt
+	pendingAuth.lock.Unlock()
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	*reply = Status{
+		Connected:   isLoopRunning(),
+		State:       State().String(),
+		WorldName:   lang.StripFormatting(serverInfo.WorldName),
+		Dimension:   dimension,
+		Position:    selfPosition,
+		Players:     len(players),
+		Vehicle:     selfVehicle,
+		OnFire:      selfOnFire,
+		PendingAuth: prompt,
+	}
+	return nil
+}
+
+func (BotRPC) Chat(msg string, reply *Empty) error {
+	sendChat(msg)
+	return nil
+}
+
+func (BotRPC) Players(args Empty, reply *[]Player) error {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	for _, v := range players {
+		*reply = append(*reply, *v)
+	}
+	return nil
+}
+
+type BehaviorToggle struct {
+	Name    string
+	Enabled bool
+}
+
+func (BotRPC) SetBehavior(args BehaviorToggle, reply *Empty) error {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	return behaviors.SetEnabled(args.Name, args.Enabled)
+}
+
+func (BotRPC) Behaviors(args Empty, reply *[]BehaviorStatus) error {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	*reply = behaviors.Status()
+	return nil
+}
+
+func (BotRPC) Inventory(args Empty, reply *[]InventorySlot) error {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	*reply = inventorySnapshot()
+	return nil
+}
+
+// PlayerChat returns the recent messages of the player named name, oldest first
+func (BotRPC) PlayerChat(name string, reply *[]ChatMessage) error {
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	*reply = append(*reply, playerChat[strings.ToLower(name)]...)
+	return nil
+}
+
+// serveRPC serves BotRPC as JSON-RPC on a unix socket at path, only reachable by the bot user
+func serveRPC(path string) error {
+	_ = os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return err
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Bot", BotRPC{}); err != nil {
+		_ = l.Close()
+		return err
+	}
+
+	log.Infof("JSON-RPC listening on %s\n", path)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				log.Errorf("Error accepting JSON-RPC connection: %s\n", err)
+				return
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/racerxdl/minebot/config"
+)
+
+// lastRuleMatch is when each message rule last matched, by index, rules are debounced by their Cooldown
+var lastRuleMatch = map[int]time.Time{}
+
+// handleMessageRules takes the action of every message rule matching the translated server message msg
+func handleMessageRules(conn ServerConn, msg string) {
+	for i, rule := range cfg.Rules.Messages {
+		if time.Since(lastRuleMatch[i]) < rule.Cooldown {
+			continue
+		}
+		arg, ok := rule.Matches("ptbr", msg)
+		if !ok {
+			continue
+		}
+		lastRuleMatch[i] = time.Now()
+		log.Infof("Message rule %d matched, taking action %s %q: %s\n", i, rule.Action, arg, msg)
+		runRuleAction(conn, rule.Action, arg)
+	}
+}
+
+func runRuleAction(conn ServerConn, action, arg string) {
+	switch action {
+	case config.ActionCommand:
+		if err := sendCommand(conn, arg, uuid.New()); err != nil {
+			log.Errorf("Error sending command %q: %s\n", arg, err)
+		}
+	case config.ActionChat:
+		sendChat(arg)
+	case config.ActionReconnect:
+		delay, _ := time.ParseDuration(arg)
+		atomic.StoreInt64(&reconnectDelay, int64(delay))
+		select {
+		case reconnectRequest <- struct{}{}:
+		default:
+		}
+	case config.ActionBehavior:
+		if err := behaviors.SetEnabled(arg, true); err != nil {
+			log.Errorf("Error enabling behavior %q: %s\n", arg, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestMessageRules(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, `
+[[Rules.Messages]]
+Match = '^(\w+) joined$'
+Regex = true
+Action = "chat"
+Argument = "welcome $1"
+Cooldown = "1m"
+
+[[Rules.Messages]]
+Match = "vote now"
+Action = "command"
+Argument = "/vote 1"
+`)
+	lastRuleMatch = map[int]time.Time{}
+	t.Cleanup(func() { lastRuleMatch = map[int]time.Time{} })
+	drainChat()
+
+	handleMessageRules(conn, "Steve joined")
+	if got := drainChat(); len(got) != 1 || got[0] != "welcome Steve" {
+		t.Fatalf("chat after a join = %q, want [welcome Steve]", got)
+	}
+	// The rule is debounced by its cooldown
+	handleMessageRules(conn, "Alex joined")
+	if got := drainChat(); len(got) != 0 {
+		t.Fatalf("rule matched again during its cooldown: %q", got)
+	}
+	lastRuleMatch[0] = time.Now().Add(-time.Minute)
+	handleMessageRules(conn, "Alex joined")
+	if got := drainChat(); len(got) != 1 || got[0] != "welcome Alex" {
+		t.Fatalf("chat after the cooldown = %q, want [welcome Alex]", got)
+	}
+
+	// Without a cooldown a rule matches every time
+	for i := 0; i < 2; i++ {
+		handleMessageRules(conn, "Please vote now!")
+	}
+	var commands []string
+	for _, pk := range conn.Written() {
+		if cmd, ok := pk.(*packet.CommandRequest); ok {
+			commands = append(commands, cmd.CommandLine)
+		}
+	}
+	if len(commands) != 2 || commands[0] != "/vote 1" {
+		t.Errorf("commands sent = %q, want /vote 1 twice", commands)
+	}
+}
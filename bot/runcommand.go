@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+var errCommandTimeout = errors.New("no output received for command")
+var errUnknownCommand = errors.New("the server has no such command")
+
+// ServerCommand is a slash command available to the bot, as sent by the server
+type ServerCommand struct {
+	Name    string
+	Aliases []string
+	// Parameters holds the parameter count of each overload
+	Parameters []int
+}
+
+// serverCommands maps the names and aliases of the commands available to the bot to the command
+var serverCommands = map[string]ServerCommand{}
+
+func setServerCommands(pk *packet.AvailableCommands) {
+	serverCommands = map[string]ServerCommand{}
+	for _, v := range pk.Commands {
+		cmd := ServerCommand{Name: v.Name, Aliases: v.Aliases}
+		for _, o := range v.Overloads {
+			cmd.Parameters = append(cmd.Parameters, len(o.Parameters))
+		}
+		serverCommands[v.Name] = cmd
+		for _, alias := range v.Aliases {
+			serverCommands[alias] = cmd
+		}
+	}
+	log.Infof("Server has %d commands available\n", len(pk.Commands))
+}
+
+// hasServerCommand tells whether the command line runs a command available to the bot. It is true
+// until the server sent the available commands.
+func hasServerCommand(line string) bool {
+	fields := strings.Fields(strings.TrimPrefix(line, "/"))
+	if len(fields) == 0 {
+		return false
+	}
+	playersLock.Lock()
+	defer playersLock.Unlock()
+	if len(serverCommands) == 0 {
+		return true
+	}
+	_, ok := serverCommands[strings.ToLower(fields[0])]
+	return ok
+}
+
+// pendingCommands maps the origin UUID of commands sent by runCommand to the channel waiting for their output
+var pendingCommands = map[uuid.UUID]chan *packet.CommandOutput{}
+var pendingCommandsLock sync.Mutex
+
+// runCommand runs the slash command line as the bot and waits up to timeout for its output. The
+// output is read by the RX loop, so this must not be called from it.
+func runCommand(conn ServerConn, line string, timeout time.Duration) (*packet.CommandOutput, error) {
+	if !hasServerCommand(line) {
+		return nil, errUnknownCommand
+	}
+	id := uuid.New()
+	out := make(chan *packet.CommandOutput, 1)
+	registerCommand(id, out)
+	defer unregisterCommand(id)
+
+	if err := sendCommand(conn, line, id); err != nil {
+		return nil, err
+	}
+
+	select {
+	case output := <-out:
+		return output, nil
+	case <-time.After(timeout):
+		return nil, errCommandTimeout
+	}
+}
+
+// streamCommand runs the slash command line as the bot and returns a channel receiving each of its
+// outputs, for commands that report progress. The channel is closed once no output came for idle.
+// Like runCommand, it must not be called from the RX loop.
+func streamCommand(conn ServerConn, line string, idle time.Duration) (<-chan *packet.CommandOutput, error) {
+	if !hasServerCommand(line) {
+		return nil, errUnknownCommand
+	}
+	id := uuid.New()
+	in := make(chan *packet.CommandOutput, 16)
+	registerCommand(id, in)
+	if err := sendCommand(conn, line, id); err != nil {
+		unregisterCommand(id)
+		return nil, err
+	}
+
+	out := make(chan *packet.CommandOutput)
+	go func() {
+		defer close(out)
+		defer unregisterCommand(id)
+		for {
+			select {
+			case output := <-in:
+				out <- output
+			case <-time.After(idle):
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func registerCommand(id uuid.UUID, out chan *packet.CommandOutput) {
+	pendingCommandsLock.Lock()
+	defer pendingCommandsLock.Unlock()
+	pendingCommands[id] = out
+}
+
+func unregisterCommand(id uuid.UUID) {
+	pendingCommandsLock.Lock()
+	defer pendingCommandsLock.Unlock()
+	delete(pendingCommands, id)
+}
+
+// sendCommand sends the slash command line as the bot without waiting for its output
+func sendCommand(conn ServerConn, line string, id uuid.UUID) error {
+	return writePacket(conn, &packet.CommandRequest{
+		CommandLine: "/" + strings.TrimPrefix(line, "/"),
+		CommandOrigin: protocol.CommandOrigin{
+			Origin: protocol.CommandOriginPlayer,
+			UUID:   id,
+		},
+	})
+}
+
+// handleCommandOutput delivers output to the runCommand call waiting for it, if any
+func handleCommandOutput(output *packet.CommandOutput) {
+	pendingCommandsLock.Lock()
+	defer pendingCommandsLock.Unlock()
+	if out, ok := pendingCommands[output.CommandOrigin.UUID]; ok {
+		select {
+		case out <- output:
+		default:
+		}
+	}
+}
+
+// commandOutputText translates the messages in output, one per line
+func commandOutputText(output *packet.CommandOutput) string {
+	lines := make([]string, len(output.OutputMessages))
+	for i, v := range output.OutputMessages {
+		lines[i] = translate("ptbr", v.Message, v.Parameters)
+	}
+	return strings.Join(lines, "\n")
+}
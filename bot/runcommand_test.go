@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestStreamCommand(t *testing.T) {
+	conn := startTestSession(t)
+	out, err := streamCommand(conn, "/fill 0 0 0 10 10 10 stone", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := conn.Written()
+	if len(written) != 1 {
+		t.Fatalf("%d packets written, want the command request", len(written))
+	}
+	id := written[0].(*packet.CommandRequest).CommandOrigin.UUID
+
+	output := func(id uuid.UUID, msg string) *packet.CommandOutput {
+		return &packet.CommandOutput{
+			CommandOrigin:  protocol.CommandOrigin{UUID: id},
+			OutputMessages: []protocol.CommandOutputMessage{{Message: msg}},
+		}
+	}
+	handleCommandOutput(output(id, "10 blocks"))
+	handleCommandOutput(output(uuid.New(), "another command"))
+	handleCommandOutput(output(id, "50 blocks"))
+	handleCommandOutput(output(id, "done"))
+
+	var got []string
+	for v := range out {
+		got = append(got, commandOutputText(v))
+	}
+	if len(got) != 3 || got[0] != "10 blocks" || got[1] != "50 blocks" || got[2] != "done" {
+		t.Errorf("streamed %q, want the three outputs of the command in order", got)
+	}
+	pendingCommandsLock.Lock()
+	defer pendingCommandsLock.Unlock()
+	if _, ok := pendingCommands[id]; ok {
+		t.Error("the command is still pending once its stream closed")
+	}
+}
@@ -0,0 +1,87 @@
+package bot
+
+import "sync/atomic"
+
+// ConnectionState is where the bot is in its connection lifecycle
+type ConnectionState int32
+
+const (
+	StateStopped ConnectionState = iota
+	StateConnecting
+	StateAuthenticating
+	StateSpawning
+	StatePlaying
+	StateReconnecting
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateConnecting:
+		return "connecting"
+	case StateAuthenticating:
+		return "authenticating"
+	case StateSpawning:
+		return "spawning"
+	case StatePlaying:
+		return "playing"
+	case StateReconnecting:
+		return "reconnecting"
+	}
+	return "unknown"
+}
+
+// stateTransitions lists the states each state can go to. Any state can go to StateStopped. Spawning
+// goes back to connecting when the dial fails after StartGame.
+var stateTransitions = map[ConnectionState][]ConnectionState{
+	StateStopped:        {StateConnecting},
+	StateConnecting:     {StateAuthenticating},
+	StateAuthenticating: {StateSpawning, StateConnecting},
+	StateSpawning:       {StatePlaying, StateReconnecting, StateConnecting},
+	StatePlaying:        {StateReconnecting},
+	StateReconnecting:   {StateConnecting},
+}
+
+// connState is the current ConnectionState. It is set from the dialer and both event loops.
+var connState int32
+
+// OnStateChange is called after each change of the connection state, from the goroutine making it.
+// It may be nil.
+var OnStateChange func(from, to ConnectionState)
+
+// State returns the current connection state
+func State() ConnectionState {
+	return ConnectionState(atomic.LoadInt32(&connState))
+}
+
+// setState moves to state s. Unexpected transitions are still made, but logged as warnings.
+func setState(s ConnectionState) {
+	from := ConnectionState(atomic.SwapInt32(&connState, int32(s)))
+	if from == s {
+		return
+	}
+	if !validTransition(from, s) {
+		log.Warnf("Unexpected connection state change from %s to %s\n", from, s)
+	}
+	onStateChange(from, s)
+}
+
+func validTransition(from, to ConnectionState) bool {
+	if to == StateStopped {
+		return true
+	}
+	for _, v := range stateTransitions[from] {
+		if v == to {
+			return true
+		}
+	}
+	return false
+}
+
+func onStateChange(from, to ConnectionState) {
+	log.Infof("Connection state %s -> %s\n", from, to)
+	if OnStateChange != nil {
+		OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidTransition(t *testing.T) {
+	tests := []struct {
+		from, to ConnectionState
+		valid    bool
+	}{
+		{StateStopped, StateConnecting, true},
+		{StateConnecting, StateAuthenticating, true},
+		{StateAuthenticating, StateSpawning, true},
+		{StateAuthenticating, StateConnecting, true},
+		{StateSpawning, StatePlaying, true},
+		{StateSpawning, StateReconnecting, true},
+		{StateSpawning, StateConnecting, true},
+		{StatePlaying, StateReconnecting, true},
+		{StateReconnecting, StateConnecting, true},
+		{StatePlaying, StateStopped, true},
+		{StateConnecting, StateStopped, true},
+
+		{StateStopped, StatePlaying, false},
+		{StateConnecting, StatePlaying, false},
+		{StatePlaying, StateConnecting, false},
+		{StatePlaying, StateSpawning, false},
+		{StateReconnecting, StatePlaying, false},
+	}
+	for _, test := range tests {
+		if got := validTransition(test.from, test.to); got != test.valid {
+			t.Errorf("%s -> %s valid %v, want %v", test.from, test.to, got, test.valid)
+		}
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	setState(StateStopped)
+	var changes [][2]ConnectionState
+	OnStateChange = func(from, to ConnectionState) {
+		changes = append(changes, [2]ConnectionState{from, to})
+	}
+	t.Cleanup(func() {
+		OnStateChange = nil
+		setState(StateStopped)
+	})
+
+	for _, s := range []ConnectionState{StateConnecting, StateAuthenticating, StateAuthenticating, StateSpawning, StatePlaying} {
+		setState(s)
+	}
+	want := [][2]ConnectionState{
+		{StateStopped, StateConnecting},
+		{StateConnecting, StateAuthenticating},
+		{StateAuthenticating, StateSpawning},
+		{StateSpawning, StatePlaying},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+	if State() != StatePlaying {
+		t.Errorf("state is %s, want playing", State())
+	}
+}
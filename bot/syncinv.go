@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// inventorySync is a pending !syncinv. Opening the inventory makes the server send its content again,
+// which replaces the tracked slots.
+var inventorySync struct {
+	// deadline is when the pending sync is given up, the server may never send the inventory
+	deadline time.Time
+	// opened is set once the inventory opened by the sync was closed, later containers are left alone
+	opened bool
+	before int
+}
+
+// inventorySyncTimeout is how long a !syncinv waits for the inventory
+const inventorySyncTimeout = 5 * time.Second
+
+func inventorySyncPending() bool {
+	return time.Now().Before(inventorySync.deadline)
+}
+
+// requestInventorySync opens the bot inventory so the server resends it
+func requestInventorySync(conn ServerConn) error {
+	inventorySync.deadline = time.Now().Add(inventorySyncTimeout)
+	inventorySync.opened = false
+	inventorySync.before = len(inventorySnapshot())
+	return writePacket(conn, &packet.Interact{
+		ActionType:            packet.InteractActionOpenInventory,
+		TargetEntityRuntimeID: selfRuntimeID,
+	})
+}
+
+// onSyncContainerOpen closes the inventory opened by a pending sync
+func onSyncContainerOpen(conn ServerConn, open *packet.ContainerOpen) {
+	if !inventorySyncPending() || inventorySync.opened {
+		return
+	}
+	inventorySync.opened = true
+	if err := writePacket(conn, &packet.ContainerClose{WindowID: open.WindowID}); err != nil {
+		log.Errorf("Error closing inventory: %s\n", err)
+	}
+}
+
+// onSyncInventoryContent reports a pending sync once the server sent the inventory
+func onSyncInventoryContent(windowID uint32) {
+	if !inventorySyncPending() || windowID != protocol.WindowIDInventory {
+		return
+	}
+	inventorySync.deadline = time.Time{}
+	sendChat(fmt.Sprintf("Inventory synced: %d slots in use before, %d now", inventorySync.before, len(inventorySnapshot())))
+}
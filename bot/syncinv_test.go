@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestInventorySync(t *testing.T) {
+	conn := testItems(t)
+	setTestConfig(t, "[Connection]\nAllowedNames = [\"Admin\"]\n")
+	inventory[protocol.WindowIDInventory] = []protocol.ItemInstance{stack(264, 1)}
+
+	if got := runAdminCommand(t, conn, "!syncinv"); len(got) != 0 {
+		t.Fatalf("!syncinv replied %q before the inventory came", got)
+	}
+	handlePacket(conn, &packet.ContainerOpen{WindowID: 1})
+	handlePacket(conn, &packet.InventoryContent{WindowID: protocol.WindowIDInventory, Content: []protocol.ItemInstance{stack(264, 1), stack(3, 32), stack(3, 8)}})
+	if got, want := drainChat(), []string{"Inventory synced: 1 slots in use before, 3 now"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sync reported %q, want %q", got, want)
+	}
+	written := conn.Written()
+	if len(written) != 2 {
+		t.Fatalf("%d packets written, want the inventory opened and closed", len(written))
+	}
+	if open, ok := written[0].(*packet.Interact); !ok || open.ActionType != packet.InteractActionOpenInventory {
+		t.Errorf("first packet is %#v, want the inventory opened", written[0])
+	}
+	if closed, ok := written[1].(*packet.ContainerClose); !ok || closed.WindowID != 1 {
+		t.Errorf("second packet is %#v, want window 1 closed", written[1])
+	}
+
+	// Containers opened afterwards are left alone
+	handlePacket(conn, &packet.ContainerOpen{WindowID: 2})
+	if n := len(conn.Written()); n != 2 {
+		t.Errorf("%d packets written after the sync, want none", n-2)
+	}
+}
+
+func TestInventorySyncExpires(t *testing.T) {
+	conn := testItems(t)
+	if err := requestInventorySync(conn); err != nil {
+		t.Fatal(err)
+	}
+	// The server opened a chest instead of the inventory, then never sent it
+	handlePacket(conn, &packet.ContainerOpen{WindowID: 1})
+	handlePacket(conn, &packet.ContainerOpen{WindowID: 2})
+	if n := len(conn.Written()); n != 2 {
+		t.Errorf("%d packets written, want only the first container closed", n)
+	}
+
+	inventorySync.deadline = time.Now().Add(-time.Second)
+	drainChat()
+	handlePacket(conn, &packet.InventoryContent{WindowID: protocol.WindowIDInventory, Content: []protocol.ItemInstance{stack(3, 1)}})
+	if got := drainChat(); len(got) != 0 {
+		t.Errorf("an expired sync reported %q", got)
+	}
+	if n := len(inventory[protocol.WindowIDInventory]); n != 1 {
+		t.Errorf("inventory has %d slots, want the content applied anyway", n)
+	}
+}
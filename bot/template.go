@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderTemplate fills the placeholders in tmpl from the bot state:
+//
+//	{player} the player the message is about
+//	{pos} the bot position, also available as {x}, {y} and {z}
+//	{time} the world time of day as HH:MM
+//	{count} the number of tracked players
+//
+// Unknown placeholders are kept as is. The result is plain text and must not be used as a format string.
+func renderTemplate(tmpl, player string) string {
+	pos := strings.Fields(formatPosition(selfPosition))
+	tod := timeOfDay(worldTime)
+	// Tick 0 is 06:00
+	clock := fmt.Sprintf("%02d:%02d", (tod/1000+6)%24, tod%1000*60/1000)
+	r := strings.NewReplacer(
+		"{player}", player,
+		"{pos}", strings.Join(pos, " "),
+		"{x}", pos[0],
+		"{y}", pos[1],
+		"{z}", pos[2],
+		"{time}", clock,
+		"{count}", fmt.Sprint(len(players)),
+	)
+	return r.Replace(tmpl)
+}
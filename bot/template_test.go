@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	startTestSession(t)
+	selfPosition = mgl32.Vec3{10.4, 64, -3.6}
+	// Six hours and a half after 06:00
+	worldTime = ticksPerDay*3 + 6500
+	players[2] = &Player{Username: "Steve"}
+	players[3] = &Player{Username: "Alex"}
+	t.Cleanup(func() { players = map[uint64]*Player{} })
+
+	tests := []struct {
+		tmpl, want string
+	}{
+		{"{player}", "Steve"},
+		{"{pos}", "10 64 -4"},
+		{"{x}/{y}/{z}", "10/64/-4"},
+		{"{time}", "12:30"},
+		{"{count}", "2"},
+		{"RIP {player} at {pos}", "RIP Steve at 10 64 -4"},
+		// Unknown placeholders and format verbs are kept
+		{"{unknown} {player", "{unknown} {player"},
+		{"100%s {player}", "100%s Steve"},
+	}
+	for _, test := range tests {
+		if got := renderTemplate(test.tmpl, "Steve"); got != test.want {
+			t.Errorf("renderTemplate(%q) = %q, want %q", test.tmpl, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import "time"
+
+// The server's tick rate is estimated from the world time carried by SetTime packets, which servers
+// send every few seconds: the ticks elapsed between the oldest and newest sample divided by the wall
+// time between them. It can't be estimated when the daylight cycle is stopped, as the time doesn't move.
+
+// maxTPSSamples is how many SetTime samples the estimate is made from
+const maxTPSSamples = 8
+
+type tpsSample struct {
+	At    time.Time
+	Ticks int64
+}
+
+// tpsSamples holds the latest SetTime samples, oldest first
+var tpsSamples []tpsSample
+
+// addTPSSample records the world time received at now. A jump backwards or faster than twice the
+// normal rate is a /time set, the samples before it are dropped.
+func addTPSSample(now time.Time, ticks int64) {
+	if n := len(tpsSamples); n > 0 {
+		last := tpsSamples[n-1]
+		elapsed := ticks - last.Ticks
+		if elapsed < 0 || float64(elapsed) > now.Sub(last.At).Seconds()*40+1 {
+			tpsSamples = nil
+		}
+	}
+	tpsSamples = append(tpsSamples, tpsSample{At: now, Ticks: ticks})
+	if len(tpsSamples) > maxTPSSamples {
+		tpsSamples = tpsSamples[1:]
+	}
+}
+
+// serverTPS returns the estimated server ticks per second, or false if there are not enough samples
+// or the world time doesn't move
+func serverTPS() (float64, bool) {
+	if len(tpsSamples) < 2 {
+		return 0, false
+	}
+	first, last := tpsSamples[0], tpsSamples[len(tpsSamples)-1]
+	seconds := last.At.Sub(first.At).Seconds()
+	if seconds <= 0 || last.Ticks == first.Ticks {
+		return 0, false
+	}
+	return float64(last.Ticks-first.Ticks) / seconds, true
+}
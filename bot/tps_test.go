@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestServerTPS(t *testing.T) {
+	tpsSamples = nil
+	t.Cleanup(func() { tpsSamples = nil })
+	start := time.Unix(1000, 0)
+
+	addTPSSample(start, 1000)
+	if _, ok := serverTPS(); ok {
+		t.Error("estimated the TPS from a single sample")
+	}
+	// 150 ticks in 10 seconds
+	addTPSSample(start.Add(5*time.Second), 1080)
+	addTPSSample(start.Add(10*time.Second), 1150)
+	if tps, ok := serverTPS(); !ok || math.Abs(tps-15) > 0.001 {
+		t.Errorf("got %g TPS (%v), want 15", tps, ok)
+	}
+
+	// Only the latest samples are kept
+	for i := 1; i <= maxTPSSamples; i++ {
+		addTPSSample(start.Add(time.Duration(10+i)*time.Second), 1150+int64(i)*20)
+	}
+	if len(tpsSamples) != maxTPSSamples {
+		t.Errorf("kept %d samples, want %d", len(tpsSamples), maxTPSSamples)
+	}
+	if tps, _ := serverTPS(); math.Abs(tps-20) > 0.001 {
+		t.Errorf("got %g TPS from the latest samples, want 20", tps)
+	}
+}
+
+func TestServerTPSTimeSet(t *testing.T) {
+	tpsSamples = nil
+	t.Cleanup(func() { tpsSamples = nil })
+	start := time.Unix(1000, 0)
+
+	addTPSSample(start, 1000)
+	addTPSSample(start.Add(5*time.Second), 1100)
+	// /time set backwards and forwards drop the older samples
+	addTPSSample(start.Add(10*time.Second), 0)
+	if len(tpsSamples) != 1 {
+		t.Errorf("kept %d samples after a jump backwards, want 1", len(tpsSamples))
+	}
+	addTPSSample(start.Add(15*time.Second), 100000)
+	if _, ok := serverTPS(); ok {
+		t.Error("estimated the TPS across a jump forwards")
+	}
+
+	// A stopped daylight cycle can't be estimated
+	tpsSamples = nil
+	addTPSSample(start, 6000)
+	addTPSSample(start.Add(5*time.Second), 6000)
+	if _, ok := serverTPS(); ok {
+		t.Error("estimated the TPS with the time not moving")
+	}
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// transcript appends the public chat to Chat.TranscriptDir, in one file per day
+var transcript struct {
+	day string
+	f   *os.File
+}
+
+// writeTranscript adds a public chat message to the transcript of the current day
+func writeTranscript(sender, msg string, translated bool) {
+	if cfg.Chat.TranscriptDir == "" {
+		return
+	}
+	now := time.Now()
+	if day := now.Format("2006-01-02"); day != transcript.day {
+		if transcript.f != nil {
+			_ = transcript.f.Close()
+			transcript.f = nil
+		}
+		path := filepath.Join(cfg.Chat.TranscriptDir, fmt.Sprintf("chat-%s.log", day))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Errorf("Error opening chat transcript: %s\n", err)
+			return
+		}
+		transcript.day = day
+		transcript.f = f
+	}
+	_, err := fmt.Fprintf(transcript.f, "%s\t%s\t%t\t%s\n", now.Format(time.RFC3339), sender, translated, msg)
+	if err != nil {
+		log.Errorf("Error writing chat transcript: %s\n", err)
+	}
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/racerxdl/minebot/lang"
+)
+
+// lastReconnectTrigger is when a reconnect trigger last matched, they are debounced by
+// Reconnect.TriggerCooldown
+var lastReconnectTrigger time.Time
+
+// reconnectDelay is how long in nanoseconds to wait before the requested reconnect, it is reset once used
+var reconnectDelay int64
+
+// handleReconnectTrigger disconnects and schedules a delayed reconnect when msg matches a reconnect
+// trigger, so a server restart doesn't end up in a kick
+func handleReconnectTrigger(msg string) {
+	if time.Since(lastReconnectTrigger) < cfg.Reconnect.TriggerCooldown {
+		return
+	}
+	if !matchesAny(cfg.Reconnect.Triggers, cfg.Reconnect.TriggerKeys, msg) {
+		return
+	}
+	lastReconnectTrigger = time.Now()
+	log.Warnf("Reconnect trigger matched, reconnecting in %s: %s\n", cfg.Reconnect.TriggerDelay, msg)
+	atomic.StoreInt64(&reconnectDelay, int64(cfg.Reconnect.TriggerDelay))
+	select {
+	case reconnectRequest <- struct{}{}:
+	default:
+	}
+}
+
+// matchesAny tells whether msg matches one of the regular expressions in patterns, or is the
+// message of one of the translation keys in keys
+func matchesAny(patterns, keys []string, msg string) bool {
+	for _, v := range patterns {
+		if re := cfg.Regexp(v); re != nil && re.MatchString(msg) {
+			return true
+		}
+	}
+	for _, v := range keys {
+		if lang.MatchesTranslated("ptbr", v, msg) {
+			return true
+		}
+	}
+	return false
+}
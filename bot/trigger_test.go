@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// reconnectRequested tells whether a reconnect was requested, and clears the request
+func reconnectRequested() bool {
+	select {
+	case <-reconnectRequest:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestReconnectTrigger(t *testing.T) {
+	setTestConfig(t, `
+[Reconnect]
+Triggers = ["^Server restarting"]
+TriggerKeys = ["multiplayer.player.left"]
+TriggerDelay = "30s"
+TriggerCooldown = "1m"
+`)
+	lastReconnectTrigger = time.Time{}
+	t.Cleanup(func() {
+		lastReconnectTrigger = time.Time{}
+		atomic.StoreInt64(&reconnectDelay, 0)
+		reconnectRequested()
+	})
+	reconnectRequested()
+
+	handleReconnectTrigger("The server is restarting")
+	if reconnectRequested() {
+		t.Fatal("reconnecting on a message not matching the triggers")
+	}
+	handleReconnectTrigger("Server restarting in 10 seconds")
+	if !reconnectRequested() {
+		t.Fatal("not reconnecting on a trigger")
+	}
+	if delay := time.Duration(atomic.LoadInt64(&reconnectDelay)); delay != 30*time.Second {
+		t.Errorf("reconnecting in %s, want 30s", delay)
+	}
+
+	// Servers repeat their restart announcements
+	handleReconnectTrigger("Server restarting in 5 seconds")
+	if reconnectRequested() {
+		t.Fatal("reconnecting again during the trigger cooldown")
+	}
+
+	lastReconnectTrigger = time.Now().Add(-time.Minute)
+	handleReconnectTrigger("Steve saiu do jogo")
+	if !reconnectRequested() {
+		t.Error("not reconnecting on a trigger key after the cooldown")
+	}
+}
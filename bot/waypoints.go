@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/racerxdl/minebot/store"
+)
+
+// botStore persists the bot state that must survive restarts, files in the working directory by default
+var botStore store.Store = store.FileStore{}
+
+const waypointsKey = "waypoints.json"
+
+type Waypoint struct {
+	Position  mgl32.Vec3
+	Dimension int32
+}
+
+// waypoints are the named positions recorded with !mark, by lowercase name. Loaded on first use.
+var waypoints map[string]Waypoint
+
+func loadWaypoints() error {
+	if waypoints != nil {
+		return nil
+	}
+	data, err := botStore.Get(waypointsKey)
+	if errors.Is(err, os.ErrNotExist) {
+		waypoints = map[string]Waypoint{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w := map[string]Waypoint{}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	waypoints = w
+	return nil
+}
+
+// markWaypoint records the bot position as name and saves the waypoints
+func markWaypoint(name string) error {
+	if err := loadWaypoints(); err != nil {
+		return err
+	}
+	waypoints[strings.ToLower(name)] = Waypoint{Position: selfPosition, Dimension: dimension}
+	data, err := json.Marshal(waypoints)
+	if err != nil {
+		return err
+	}
+	return botStore.Set(waypointsKey, data)
+}
+
+func findWaypoint(name string) (Waypoint, bool, error) {
+	if err := loadWaypoints(); err != nil {
+		return Waypoint{}, false, err
+	}
+	w, ok := waypoints[strings.ToLower(name)]
+	return w, ok, nil
+}
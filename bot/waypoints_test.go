@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/racerxdl/minebot/store"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// useStore replaces botStore with an empty MemoryStore for the duration of the test
+func useStore(t *testing.T) *store.MemoryStore {
+	t.Helper()
+	s := store.NewMemoryStore()
+	old := botStore
+	botStore = s
+	waypoints = nil
+	t.Cleanup(func() {
+		botStore = old
+		waypoints = nil
+	})
+	return s
+}
+
+func TestWaypoints(t *testing.T) {
+	conn := startTestSession(t)
+	setTestConfig(t, "[Connection]\nAllowedNames = [\"Admin\"]\n")
+	s := useStore(t)
+
+	selfPosition = mgl32.Vec3{100, 64, -20}
+	if got, want := runAdminCommand(t, conn, "!mark Home"), []string{"Marked Home at 100 64 -20"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("!mark replied %q, want %q", got, want)
+	}
+	if _, err := s.Get(waypointsKey); err != nil {
+		t.Fatalf("waypoints not saved: %s", err)
+	}
+
+	// A restart loads them back from the store
+	waypoints = nil
+	selfPosition = mgl32.Vec3{100, 64, 10}
+	if got, want := runAdminCommand(t, conn, "!waypoint home"), []string{"home is at 100 64 -20, 30 blocks away"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("!waypoint replied %q, want %q", got, want)
+	}
+	dimension = 1
+	if got, want := runAdminCommand(t, conn, "!waypoint HOME"), []string{"HOME is at 100 64 -20 in dimension 0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("!waypoint in another dimension replied %q, want %q", got, want)
+	}
+	if got, want := runAdminCommand(t, conn, "!waypoint work"), []string{"There is no waypoint work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("!waypoint for an unknown name replied %q, want %q", got, want)
+	}
+
+	// Only allowed users mark waypoints
+	handlePacket(conn, &packet.Text{TextType: packet.TextTypeChat, SourceName: "Steve", Message: "!mark Home"})
+	if w, _, _ := findWaypoint("home"); w.Position != (mgl32.Vec3{100, 64, -20}) {
+		t.Errorf("Steve moved the waypoint to %v", w.Position)
+	}
+	drainChat()
+}
+
+func TestWaypointsCorrupted(t *testing.T) {
+	s := useStore(t)
+	if err := s.Set(waypointsKey, []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := findWaypoint("home"); err == nil {
+		t.Error("loaded corrupted waypoints")
+	}
+}
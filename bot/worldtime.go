@@ -0,0 +1,41 @@
+package bot
+
+// Ticks in a Minecraft day, and the part of it that counts as night
+const (
+	ticksPerDay = 24000
+	nightStart  = 13000
+	nightEnd    = 23000
+)
+
+// worldTime is the total world time in ticks, as sent by the server
+var worldTime int64
+
+func timeOfDay(ticks int64) int64 {
+	return ((ticks % ticksPerDay) + ticksPerDay) % ticksPerDay
+}
+
+func dayCount(ticks int64) int64 {
+	return ticks / ticksPerDay
+}
+
+func isNight(ticks int64) bool {
+	t := timeOfDay(ticks)
+	return t >= nightStart && t < nightEnd
+}
+
+// setWorldTime updates worldTime and fires onDayNightChange when it crosses into day or night
+func setWorldTime(ticks int64) {
+	wasNight := isNight(worldTime)
+	worldTime = ticks
+	if isNight(worldTime) != wasNight {
+		onDayNightChange(isNight(worldTime))
+	}
+}
+
+func onDayNightChange(night bool) {
+	if night {
+		log.Infof("Night has fallen on day %d\n", dayCount(worldTime))
+	} else {
+		log.Infof("Day %d has started\n", dayCount(worldTime))
+	}
+}
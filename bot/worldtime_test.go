@@ -0,0 +1,31 @@
+package bot
+
+import "testing"
+
+func TestTimeOfDay(t *testing.T) {
+	tests := []struct {
+		ticks int64
+		time  int64
+		day   int64
+		night bool
+	}{
+		{0, 0, 0, false},
+		{12999, 12999, 0, false},
+		{13000, 13000, 0, true},
+		{22999, 22999, 0, true},
+		{23000, 23000, 0, false},
+		{24000 + 18000, 18000, 1, true},
+		{-6000, 18000, 0, true},
+	}
+	for _, test := range tests {
+		if got := timeOfDay(test.ticks); got != test.time {
+			t.Errorf("timeOfDay(%d) = %d, want %d", test.ticks, got, test.time)
+		}
+		if got := dayCount(test.ticks); got != test.day {
+			t.Errorf("dayCount(%d) = %d, want %d", test.ticks, got, test.day)
+		}
+		if got := isNight(test.ticks); got != test.night {
+			t.Errorf("isNight(%d) = %v, want %v", test.ticks, got, test.night)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/racerxdl/minebot/bot"
+
+func main() {
+	bot.Run()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/racerxdl/minebot/ping"
+)
+
+// Pings a Bedrock server. Usage: ping <host:port>
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("usage: ping <host:port>")
+		os.Exit(1)
+	}
+	status, err := ping.Ping(os.Args[1])
+	if err != nil {
+		fmt.Printf("error pinging %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s (%s)\n", status.Name, status.WorldName)
+	fmt.Printf("Version %s, protocol %d\n", status.Version, status.Protocol)
+	fmt.Printf("%d/%d players, %s\n", status.Players, status.MaxPlayers, status.GameMode)
+}
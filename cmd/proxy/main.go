@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/racerxdl/minebot/bot"
+	"github.com/racerxdl/minebot/config"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var log = logrus.New()
+var cfg config.Config
+
+// Transparent proxy for inspecting the traffic between a client and the server. Clients connect to
+// Connection.LocalAddress and are forwarded to Connection.RemoteAddress, logged in with the bot token.
+// The packets going both ways are handled by the bot as observer, and every packet is logged when
+// Log.Packets is set. Clients are proxied one at a time, as the observed bot state is global.
+func main() {
+	var err error
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		log.Fatalf("error loading config: %s\n", err)
+	}
+
+	src, err := bot.DeviceCodeAuth{OnPrompt: func(msg string) {
+		log.Warnf("Xbox Live login: %s\n", msg)
+	}}.Token()
+	if err != nil {
+		log.Fatalf("error getting token: %s\n", err)
+	}
+
+	status, err := minecraft.NewForeignStatusProvider(cfg.Connection.RemoteAddress)
+	if err != nil {
+		log.Fatalf("error reaching %s: %s\n", cfg.Connection.RemoteAddress, err)
+	}
+	listener, err := minecraft.ListenConfig{StatusProvider: status}.Listen("raknet", cfg.Connection.LocalAddress)
+	if err != nil {
+		log.Fatalf("error listening on %s: %s\n", cfg.Connection.LocalAddress, err)
+	}
+	defer listener.Close()
+	log.Infof("Proxying %s to %s\n", cfg.Connection.LocalAddress, cfg.Connection.RemoteAddress)
+
+	for {
+		c, err := listener.Accept()
+		if err != nil {
+			log.Fatalf("error accepting connection: %s\n", err)
+		}
+		handleConn(c.(*minecraft.Conn), listener, src)
+	}
+}
+
+// handleConn connects to the server on behalf of the client and pipes the packets both ways
+func handleConn(conn *minecraft.Conn, listener *minecraft.Listener, src oauth2.TokenSource) {
+	log.Infof("Client %s connected\n", conn.IdentityData().DisplayName)
+	serverConn, err := minecraft.Dialer{
+		TokenSource: src,
+		ClientData:  conn.ClientData(),
+	}.Dial("raknet", cfg.Connection.RemoteAddress)
+	if err != nil {
+		log.Errorf("Error connecting to the server: %s\n", err)
+		_ = listener.Disconnect(conn, err.Error())
+		return
+	}
+
+	var g sync.WaitGroup
+	g.Add(2)
+	go func() {
+		if err := conn.StartGame(serverConn.GameData()); err != nil {
+			log.Errorf("Error starting the client game: %s\n", err)
+		}
+		g.Done()
+	}()
+	go func() {
+		if err := serverConn.DoSpawn(); err != nil {
+			log.Errorf("Error spawning on the server: %s\n", err)
+		}
+		g.Done()
+	}()
+	g.Wait()
+
+	observer := bot.Observe(cfg, serverConn.GameData(), conn.IdentityData())
+	go func() {
+		_ = pipe("client", conn, serverConn, observer)
+		_ = serverConn.Close()
+	}()
+	err = pipe("server", serverConn, conn, observer)
+	var disconnect minecraft.DisconnectError
+	if errors.As(err, &disconnect) {
+		// Show the client why the server kicked it
+		_ = listener.Disconnect(conn, disconnect.Error())
+	}
+	_ = conn.Close()
+	log.Infof("Client %s disconnected\n", conn.IdentityData().DisplayName)
+}
+
+// pipe forwards the packets read from src to dst, logging them if enabled and passing them to
+// observer. It returns the error that stopped it.
+func pipe(name string, src, dst *minecraft.Conn, observer *bot.Observer) error {
+	for {
+		pk, err := src.ReadPacket()
+		if err != nil {
+			return err
+		}
+		if cfg.Log.Packets {
+			log.Infof("%s: %T: %+v\n", name, pk, pk)
+		}
+		if err := dst.WritePacket(pk); err != nil {
+			return err
+		}
+		observer.HandlePacket(pk)
+	}
+}
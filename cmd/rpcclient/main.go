@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// Minimal client for the bot JSON-RPC socket. Usage: rpcclient <socket> [chat message]
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("usage: rpcclient <socket> [chat message]")
+		os.Exit(1)
+	}
+	client, err := jsonrpc.Dial("unix", os.Args[1])
+	if err != nil {
+		fmt.Printf("error connecting: %s\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	if len(os.Args) > 2 {
+		if err := client.Call("Bot.Chat", os.Args[2], &struct{}{}); err != nil {
+			fmt.Printf("error sending chat: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	status := map[string]any{}
+	if err := client.Call("Bot.Status", struct{}{}, &status); err != nil {
+		fmt.Printf("error getting status: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%+v\n", status)
+}
@@ -1,20 +1,271 @@
 package config
 
 import (
+	"bytes"
 	"encoding/gob"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml"
+	"github.com/racerxdl/minebot/lang"
+	"github.com/racerxdl/minebot/store"
 	"golang.org/x/oauth2"
 )
 
+type FormResponse struct {
+	// Match is a substring looked up in the form title and content.
+	Match string
+	// Button is the index of the button to submit.
+	Button int
+	// Values are the values of a custom form's elements, by index. Toggles take true or false,
+	// sliders a number, dropdowns and step sliders the option index, and text fields the text. An
+	// empty or missing value submits the element's default.
+	Values []string
+}
+
+// Actions a MessageRule can take
+const (
+	ActionCommand   = "command"
+	ActionChat      = "chat"
+	ActionReconnect = "reconnect"
+	ActionBehavior  = "behavior"
+)
+
+type MessageRule struct {
+	// Match is a substring looked up in translated server messages, or a regular expression if Regex is set.
+	Match string
+	Regex bool
+	// Key is a translation key, such as "death.attack.player". It matches the message with that key,
+	// translated or not, instead of Match.
+	Key string
+	// Action is "command", "chat", "reconnect" or "behavior".
+	Action string
+	// Argument is the command line or chat message to send, the delay before reconnecting, or the
+	// behavior to enable. With Regex, $1 and ${name} expand to the groups of the match.
+	Argument string
+	// Cooldown ignores the rule for this long after it matched.
+	Cooldown time.Duration
+
+	// re is Match compiled by LoadConfigFrom, when Regex is set
+	re *regexp.Regexp
+}
+
+// Matches tells whether msg, translated to locale, matches the rule, and returns its argument
+// expanded with the match
+func (r MessageRule) Matches(locale, msg string) (string, bool) {
+	if r.Key != "" {
+		return r.Argument, lang.MatchesTranslated(locale, r.Key, msg)
+	}
+	if !r.Regex {
+		return r.Argument, strings.Contains(msg, r.Match)
+	}
+	re := r.re
+	if re == nil {
+		// The rule wasn't loaded by LoadConfigFrom
+		var err error
+		if re, err = regexp.Compile(r.Match); err != nil {
+			return "", false
+		}
+	}
+	m := re.FindStringSubmatchIndex(msg)
+	if m == nil {
+		return "", false
+	}
+	return string(re.ExpandString(nil, r.Argument, msg, m)), true
+}
+
 type Config struct {
 	Connection struct {
 		LocalAddress  string
 		RemoteAddress string
 		AllowedNames  []string
+		// EnableClientCache asks the server to send chunks as cacheable blobs, reducing traffic.
+		EnableClientCache bool
+		// ReadTimeout forces a reconnect when no packet is received for this long, one minute by
+		// default. A negative value disables it.
+		ReadTimeout time.Duration
+		// WriteTimeout closes the connection when a packet can't be written for this long, ten
+		// seconds by default. A negative value disables it.
+		WriteTimeout time.Duration
+	}
+	Identity struct {
+		// Offline connects without Xbox Live authentication, presenting the identity below. This only
+		// works on servers that don't verify Xbox signatures (online-mode off).
+		Offline bool
+		// XUID, DisplayName and UUID override the offline identity. Empty fields are generated.
+		XUID        string
+		DisplayName string
+		UUID        string
+	}
+	Players struct {
+		// StaleTTL evicts tracked players that were not seen for this long. Zero disables eviction.
+		StaleTTL      time.Duration
+		SweepInterval time.Duration
+		// CountRadius is the radius in blocks used by !count
+		CountRadius float32
+	}
+	Behaviors struct {
+		// StartupDelay is waited before the first connection
+		StartupDelay time.Duration
+		// StaggerWindow spreads the start of the behaviors over this long after the world loads
+		StaggerWindow time.Duration
+		// DesyncCount server corrections of the bot position within DesyncWindow are reported as a
+		// movement desync. Zero disables it.
+		DesyncCount  int
+		DesyncWindow time.Duration
+		// DesyncPause pauses the movement behaviors for DesyncWindow on a desync, to let the bot resync
+		DesyncPause bool
+		// TickRate is how many times per second the behaviors are ticked, 20 by default like the server
+		TickRate int
+		// PauseLatency pauses the movement behaviors while the latency is above it, so the bot isn't
+		// kicked for moving out of sync. Zero disables it.
+		PauseLatency time.Duration
+		// ResumeLatency resumes the movement behaviors once the latency is back under it, three
+		// quarters of PauseLatency by default
+		ResumeLatency time.Duration
+	}
+	World struct {
+		// BlockPalette is a JSON file naming the block runtime IDs of the current protocol version
+		BlockPalette string
+	}
+	RPC struct {
+		// SocketPath is where the JSON-RPC unix socket is created. Empty disables it.
+		SocketPath string
+	}
+	Log struct {
+		// ChatFormat is how chat formatting is logged: "ansi", "plain" or empty to use ANSI
+		// only when logging to a terminal
+		ChatFormat string
+		// Packets logs every packet received, can be toggled with !debug packets
+		Packets bool
+		// File is a path to also log to, without colours. Empty disables it.
+		File string
+		// FileOnly stops logging to the console when File is set
+		FileOnly bool
+		// MaxSizeMB is the size at which File is rotated
+		MaxSizeMB int
+		// MaxBackups is how many rotated files are kept
+		MaxBackups int
+		// MaxAge removes rotated files older than this. Zero keeps them.
+		MaxAge time.Duration
+		// IdleAfter only logs warnings and errors after this long without chat, deaths or
+		// disconnects, until one happens again. Zero disables it.
+		IdleAfter time.Duration
+	}
+	Chat struct {
+		// Interval is the minimum time between two messages sent by the bot
+		Interval time.Duration
+		// HereTemplate is the !here announcement. It supports {player}, {pos}, {time} and {count}.
+		HereTemplate string
+		// MaxLength is the maximum length of a message sent through !say
+		MaxLength int
+		// IgnoredPlayers are players whose messages and commands are ignored
+		IgnoredPlayers []string
+		// PersistQueue saves the messages still queued when the bot stops, and sends them after it starts
+		PersistQueue bool
+		// MaxQueueAge drops queued messages older than this instead of sending them, five minutes by
+		// default. A negative value disables it.
+		MaxQueueAge time.Duration
+		// DeathTemplate is sent when a tracked player dies, such as "RIP {player}". It supports the
+		// same placeholders as HereTemplate. Empty disables it.
+		DeathTemplate string
+		// DeathCooldown is the minimum time between two death messages about the same player, 30
+		// seconds by default
+		DeathCooldown time.Duration
+		// TranscriptDir is where the public chat is written, one chat-YYYY-MM-DD.log file per day.
+		// Empty disables it.
+		TranscriptDir string
+		// PlayerLogSize is how many recent messages of each player are kept for the PlayerChat RPC.
+		// Zero disables it.
+		PlayerLogSize int
+	}
+	Combat struct {
+		// Reach is the distance in blocks at which a player is considered in melee range
+		Reach float32
+		// Hysteresis is the extra distance a player must move away before leaving combat range
+		Hysteresis float32
+	}
+	Commands struct {
+		// ChatPrefix starts the commands sent in the public chat, "!" by default
+		ChatPrefix string
+		// WhisperPrefix starts the commands whispered to the bot. It is empty by default, so every
+		// whisper is a command.
+		WhisperPrefix string
+		// Aliases maps short command names to the command they run, such as "c" = "count". Both are case
+		// insensitive.
+		Aliases map[string]string
+	}
+	Game struct {
+		// EndPatterns are regular expressions matched against titles and server messages announcing the
+		// end of a game
+		EndPatterns []string
+		// EndKeys are translation keys of server messages announcing the end of a game
+		EndKeys []string
+		// EndOnBossBarHide also takes a boss bar disappearing as the end of a game
+		EndOnBossBarHide bool
+		// EndMessage is sent to the chat when a game ends, with the placeholders of Chat.HereTemplate.
+		// Empty disables it.
+		EndMessage string
+		// EndCooldown is how long after a game end other signals are ignored, one minute by default
+		EndCooldown time.Duration
+	}
+	Party struct {
+		// InvitePattern is a regular expression matched against server messages and forms inviting the
+		// bot to a party. Its first group must capture the inviting player's name. Empty disables it.
+		InvitePattern string
+		// AcceptCommand is the command accepting an invite from a message. {player} is the inviter, the
+		// other placeholders of Chat.HereTemplate are supported too.
+		AcceptCommand string
+		// FormButton is the button submitted to accept an invite form
+		FormButton int
+		// AllowedInviters are the players whose invites are accepted, Connection.AllowedNames if empty
+		AllowedInviters []string
+	}
+	Forms struct {
+		AutoResponses []FormResponse
+		// DismissUnknown closes any form that has no matching auto response.
+		DismissUnknown bool
+	}
+	Rules struct {
+		// Messages are evaluated in order against every translated server message, each matching
+		// rule takes its action
+		Messages []MessageRule
+	}
+	Reconnect struct {
+		// RetryableReasons is a list of regular expressions matched against the translated
+		// disconnect reason. The bot only reconnects after a kick if one of them matches.
+		RetryableReasons []string
+		// CheckNetwork checks that the server address resolves and is routable before dialing, and
+		// waits NetworkDownDelay instead of retrying right away when it isn't
+		CheckNetwork     bool
+		NetworkDownDelay time.Duration
+		// Triggers are regular expressions matched against translated server messages, such as a
+		// restart announcement. When one matches the bot disconnects, waits TriggerDelay and reconnects.
+		Triggers []string
+		// TriggerKeys are translation keys of server messages triggering a reconnect, like Triggers
+		TriggerKeys  []string
+		TriggerDelay time.Duration
+		// TriggerCooldown ignores triggers for this long after one matched, one minute by default
+		TriggerCooldown time.Duration
+		// MaxAttempts stops the bot after this many failed connection attempts in a row. Zero retries
+		// forever.
+		MaxAttempts int
+	}
+	Login struct {
+		// Prompt is a regular expression matched against server messages asking to /login
+		Prompt string
+		// RegisterPrompt is a regular expression matched against server messages asking to /register
+		RegisterPrompt string
+		// Failure is a regular expression matched against the server's wrong password message. Once it
+		// matches the bot stops trying to log in until restarted.
+		Failure string
+		// PasswordEnv is the environment variable holding the password, MINEBOT_LOGIN_PASSWORD by default
+		PasswordEnv string
 	}
 	BDS struct {
 		StartBDS bool
@@ -31,6 +282,33 @@ type Config struct {
 		ChatChannel   string
 		PlayingRoleID string
 	}
+
+	// patterns are the regular expressions of the config, compiled by LoadConfigFrom
+	patterns map[string]*regexp.Regexp
+}
+
+// compile compiles expr and keeps it for Regexp
+func (c *Config) compile(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	if c.patterns == nil {
+		c.patterns = map[string]*regexp.Regexp{}
+	}
+	c.patterns[expr] = re
+	return nil
+}
+
+// Regexp returns the compiled regular expression expr, one of the patterns of the config. It is nil
+// if expr is not a valid regular expression.
+func (c Config) Regexp(expr string) *regexp.Regexp {
+	if re, ok := c.patterns[expr]; ok {
+		return re
+	}
+	// The config wasn't loaded by LoadConfigFrom
+	re, _ := regexp.Compile(expr)
+	return re
 }
 
 func (c Config) ReverseDiscordUser(discordUsername string) string {
@@ -52,18 +330,83 @@ func (c Config) IsUserAllowed(username string) bool {
 	return false
 }
 
+func (c Config) IsInviterAllowed(username string) bool {
+	if len(c.Party.AllowedInviters) == 0 {
+		return c.IsUserAllowed(username)
+	}
+	for _, v := range c.Party.AllowedInviters {
+		if strings.EqualFold(v, username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Config) IsPlayerIgnored(username string) bool {
+	for _, v := range c.Chat.IgnoredPlayers {
+		if strings.EqualFold(v, username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Config) FormResponse(title, content string) (FormResponse, bool) {
+	for _, v := range c.Forms.AutoResponses {
+		if strings.Contains(title, v.Match) || strings.Contains(content, v.Match) {
+			return v, true
+		}
+	}
+
+	return FormResponse{}, false
+}
+
+func (c Config) IsRetryableReason(reason string) bool {
+	for _, v := range c.Reconnect.RetryableReasons {
+		if re := c.Regexp(v); re != nil && re.MatchString(reason) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fetchConfig(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("MINEBOT_CONFIG_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := &http.Client{Timeout: time.Second * 10}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching config: %s", res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
 func SaveToken(token *oauth2.Token) error {
 	return SaveTokenVariant(token, "")
 }
 
+// TokenStore is where tokens are persisted, files in the working directory by default
+var TokenStore store.Store = store.FileStore{}
+
 func SaveTokenVariant(token *oauth2.Token, variant string) error {
-	f, err := os.Create(fmt.Sprintf("token%s.gob", variant))
-	if err != nil {
+	buf := &bytes.Buffer{}
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(token); err != nil {
 		return err
 	}
-	defer f.Close()
-	enc := gob.NewEncoder(f)
-	return enc.Encode(token)
+	return TokenStore.Set(fmt.Sprintf("token%s.gob", variant), buf.Bytes())
 }
 
 func LoadToken() (*oauth2.Token, error) {
@@ -71,28 +414,166 @@ func LoadToken() (*oauth2.Token, error) {
 }
 
 func LoadTokenVariant(variant string) (*oauth2.Token, error) {
-	f, err := os.Open(fmt.Sprintf("token%s.gob", variant))
+	data, err := TokenStore.Get(fmt.Sprintf("token%s.gob", variant))
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	dec := gob.NewDecoder(f)
+	dec := gob.NewDecoder(bytes.NewReader(data))
 	tkn := &oauth2.Token{}
 	return tkn, dec.Decode(tkn)
 }
 
+var xuidPattern = regexp.MustCompile(`^[0-9]{1,16}$`)
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 func LoadConfig() (Config, error) {
-	c := Config{}
-	if _, err := os.Stat("config.toml"); err != nil {
-		return c, err
+	path := os.Getenv("MINEBOT_CONFIG")
+	if path == "" {
+		path = "config.toml"
 	}
-	data, err := ioutil.ReadFile("config.toml")
-	if err != nil {
-		return c, err
+	return LoadConfigFrom(path)
+}
+
+// LoadConfigFrom loads the config from a local file or from an http(s) URL. When fetching from a URL,
+// MINEBOT_CONFIG_TOKEN is sent as a bearer token if set.
+func LoadConfigFrom(path string) (Config, error) {
+	c := Config{}
+	var data []byte
+	var err error
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		data, err = fetchConfig(path)
+		if err != nil {
+			return c, err
+		}
+	} else {
+		if _, err := os.Stat(path); err != nil {
+			return c, err
+		}
+		data, err = ioutil.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
 	}
 	if err := toml.Unmarshal(data, &c); err != nil {
 		return c, err
 	}
+	for _, v := range c.Reconnect.RetryableReasons {
+		if err := c.compile(v); err != nil {
+			return c, fmt.Errorf("invalid retryable reason %q: %w", v, err)
+		}
+	}
+	// Command names are matched in lowercase
+	aliases := map[string]string{}
+	for k, v := range c.Commands.Aliases {
+		if _, ok := aliases[strings.ToLower(k)]; ok {
+			return c, fmt.Errorf("alias %q is defined twice", k)
+		}
+		aliases[strings.ToLower(k)] = strings.ToLower(v)
+	}
+	c.Commands.Aliases = aliases
+	if c.Commands.ChatPrefix == "" {
+		c.Commands.ChatPrefix = "!"
+	}
+	if c.Party.AcceptCommand == "" {
+		c.Party.AcceptCommand = "/party accept {player}"
+	}
+	if c.Reconnect.TriggerCooldown <= 0 {
+		c.Reconnect.TriggerCooldown = time.Minute
+	}
+	if c.Game.EndCooldown <= 0 {
+		c.Game.EndCooldown = time.Minute
+	}
+	patterns := append([]string{c.Login.Prompt, c.Login.RegisterPrompt, c.Login.Failure, c.Party.InvitePattern}, c.Game.EndPatterns...)
+	patterns = append(patterns, c.Reconnect.Triggers...)
+	for _, v := range patterns {
+		if err := c.compile(v); err != nil {
+			return c, fmt.Errorf("invalid pattern %q: %w", v, err)
+		}
+	}
+	for i, v := range c.Rules.Messages {
+		switch v.Action {
+		case ActionCommand, ActionChat, ActionBehavior:
+		case ActionReconnect:
+			if v.Argument == "" {
+				break
+			}
+			if _, err := time.ParseDuration(v.Argument); err != nil {
+				return c, fmt.Errorf("invalid reconnect delay %q in message rule %d: %w", v.Argument, i, err)
+			}
+		default:
+			return c, fmt.Errorf("unknown action %q in message rule %d", v.Action, i)
+		}
+		if v.Match == "" && v.Key == "" {
+			return c, fmt.Errorf("message rule %d has nothing to match", i)
+		}
+		if v.Regex {
+			re, err := regexp.Compile(v.Match)
+			if err != nil {
+				return c, fmt.Errorf("invalid pattern %q in message rule %d: %w", v.Match, i, err)
+			}
+			c.Rules.Messages[i].re = re
+		}
+	}
+	if c.Login.PasswordEnv == "" {
+		c.Login.PasswordEnv = "MINEBOT_LOGIN_PASSWORD"
+	}
+	if c.Identity.XUID != "" && !xuidPattern.MatchString(c.Identity.XUID) {
+		return c, fmt.Errorf("invalid XUID %q: must be up to 16 digits", c.Identity.XUID)
+	}
+	if c.Identity.UUID != "" && !uuidPattern.MatchString(c.Identity.UUID) {
+		return c, fmt.Errorf("invalid identity UUID %q", c.Identity.UUID)
+	}
+	if c.Connection.ReadTimeout == 0 {
+		c.Connection.ReadTimeout = time.Minute
+	}
+	if c.Reconnect.NetworkDownDelay <= 0 {
+		c.Reconnect.NetworkDownDelay = time.Second * 10
+	}
+	if c.Connection.WriteTimeout == 0 {
+		c.Connection.WriteTimeout = time.Second * 10
+	}
+	if c.Players.SweepInterval <= 0 {
+		c.Players.SweepInterval = time.Second * 30
+	}
+	if c.Log.MaxSizeMB <= 0 {
+		c.Log.MaxSizeMB = 10
+	}
+	if c.Log.MaxBackups <= 0 {
+		c.Log.MaxBackups = 5
+	}
+	if c.Chat.Interval <= 0 {
+		c.Chat.Interval = time.Second
+	}
+	if c.Chat.MaxLength <= 0 {
+		c.Chat.MaxLength = 256
+	}
+	if c.Chat.MaxQueueAge == 0 {
+		c.Chat.MaxQueueAge = time.Minute * 5
+	}
+	if c.Chat.DeathCooldown <= 0 {
+		c.Chat.DeathCooldown = time.Second * 30
+	}
+	if c.Chat.HereTemplate == "" {
+		c.Chat.HereTemplate = "I'm at {pos}"
+	}
+	if c.Players.CountRadius <= 0 {
+		c.Players.CountRadius = 32
+	}
+	if c.Combat.Reach <= 0 {
+		c.Combat.Reach = 3
+	}
+	if c.Combat.Hysteresis <= 0 {
+		c.Combat.Hysteresis = 0.5
+	}
+	if c.Behaviors.DesyncWindow <= 0 {
+		c.Behaviors.DesyncWindow = time.Second * 10
+	}
+	if c.Behaviors.TickRate <= 0 {
+		c.Behaviors.TickRate = 20
+	}
+	if c.Behaviors.ResumeLatency <= 0 || c.Behaviors.ResumeLatency > c.Behaviors.PauseLatency {
+		c.Behaviors.ResumeLatency = c.Behaviors.PauseLatency * 3 / 4
+	}
 	if c.Connection.LocalAddress == "" {
 		c.Connection.LocalAddress = "0.0.0.0:19132"
 	}
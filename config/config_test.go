@@ -0,0 +1,236 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testConfig = `
+[Connection]
+RemoteAddress = "play.example.com:19132"
+`
+
+func TestLoadConfigFromURL(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(testConfig))
+	}))
+	defer srv.Close()
+
+	t.Setenv("MINEBOT_CONFIG_TOKEN", "")
+	c, err := LoadConfigFrom(srv.URL)
+	if err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+	if c.Connection.RemoteAddress != "play.example.com:19132" {
+		t.Errorf("RemoteAddress is %q", c.Connection.RemoteAddress)
+	}
+	if auth != "" {
+		t.Errorf("Authorization header %q sent without a token", auth)
+	}
+
+	t.Setenv("MINEBOT_CONFIG_TOKEN", "secret")
+	if _, err := LoadConfigFrom(srv.URL); err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization header is %q, want the bearer token", auth)
+	}
+}
+
+func TestLoadConfigFromURLStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no config here", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if _, err := LoadConfigFrom(srv.URL); err == nil {
+		t.Error("loaded a config from a 404 response")
+	}
+}
+
+func TestLoadConfigFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfigFrom(path)
+	if err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+	if c.Connection.RemoteAddress != "play.example.com:19132" {
+		t.Errorf("RemoteAddress is %q", c.Connection.RemoteAddress)
+	}
+
+	if _, err := LoadConfigFrom(filepath.Join(t.TempDir(), "missing.toml")); !os.IsNotExist(err) {
+		t.Errorf("missing file returned %v, want a not exist error", err)
+	}
+}
+
+func loadTestConfig(t *testing.T, data string) (Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadConfigFrom(path)
+}
+
+func TestAliasesLowercased(t *testing.T) {
+	c, err := loadTestConfig(t, "[Commands.Aliases]\nC = \"Count\"\nup = \"uptime\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"c": "count", "up": "uptime"}
+	if !reflect.DeepEqual(c.Commands.Aliases, want) {
+		t.Errorf("aliases are %v, want %v", c.Commands.Aliases, want)
+	}
+
+	if _, err := loadTestConfig(t, "[Commands.Aliases]\nC = \"count\"\nc = \"cmd\"\n"); err == nil {
+		t.Error("no error for an alias defined twice")
+	}
+}
+
+func TestMessageRuleKey(t *testing.T) {
+	c, err := loadTestConfig(t, `
+[[Rules.Messages]]
+Key = "death.attack.player"
+Action = "chat"
+Argument = "F"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := c.Rules.Messages[0]
+	tests := []struct {
+		locale, msg string
+		want        bool
+	}{
+		{"en", "%death.attack.player", true},
+		{"en", "Steve foi morto(a) por Alex", false},
+		{"ptbr", "%death.attack.player", true},
+		{"ptbr", "Steve foi morto(a) por Alex", true},
+		{"ptbr", "Steve entrou no jogo", false},
+	}
+	for _, test := range tests {
+		arg, ok := rule.Matches(test.locale, test.msg)
+		if ok != test.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", test.locale, test.msg, ok, test.want)
+		}
+		if ok && arg != "F" {
+			t.Errorf("Matches(%q, %q) argument = %q", test.locale, test.msg, arg)
+		}
+	}
+}
+
+func TestMessageRuleMatches(t *testing.T) {
+	c, err := loadTestConfig(t, `
+[[Rules.Messages]]
+Match = "restarting"
+Action = "reconnect"
+Argument = "30s"
+
+[[Rules.Messages]]
+Match = '^(?P<player>\w+) invited you$'
+Regex = true
+Action = "command"
+Argument = "/party accept ${player}"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rule    int
+		msg     string
+		wantArg string
+		want    bool
+	}{
+		{0, "Server restarting in 30s", "30s", true},
+		{0, "Server online", "", false},
+		{1, "Steve invited you", "/party accept Steve", true},
+		{1, "Steve invited you twice", "", false},
+	}
+	for _, test := range tests {
+		arg, ok := c.Rules.Messages[test.rule].Matches("ptbr", test.msg)
+		if ok != test.want || (ok && arg != test.wantArg) {
+			t.Errorf("rule %d Matches(%q) = %q, %v, want %q, %v", test.rule, test.msg, arg, ok, test.wantArg, test.want)
+		}
+	}
+	if c.Rules.Messages[1].re == nil {
+		t.Error("regular expression rule wasn't compiled when loaded")
+	}
+}
+
+func TestMessageRuleInvalid(t *testing.T) {
+	for _, data := range []string{
+		"[[Rules.Messages]]\nMatch = \"(\"\nRegex = true\nAction = \"chat\"",
+		"[[Rules.Messages]]\nAction = \"chat\"",
+		"[[Rules.Messages]]\nMatch = \"x\"\nAction = \"explode\"",
+		"[[Rules.Messages]]\nMatch = \"x\"\nAction = \"reconnect\"\nArgument = \"soon\"",
+	} {
+		if _, err := loadTestConfig(t, data); err == nil {
+			t.Errorf("loaded invalid rules %q", data)
+		}
+	}
+}
+
+func TestRetryableReasons(t *testing.T) {
+	c, err := loadTestConfig(t, "[Reconnect]\nRetryableReasons = [\"^Server (restarting|full)\", \"timed out\"]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		reason string
+		want   bool
+	}{
+		{"Server restarting", true},
+		{"Server full, try again later", true},
+		{"Connection timed out", true},
+		{"You are banned. Server restarting", false},
+		{"Kicked by an operator", false},
+	}
+	for _, test := range tests {
+		if got := c.IsRetryableReason(test.reason); got != test.want {
+			t.Errorf("IsRetryableReason(%q) = %v, want %v", test.reason, got, test.want)
+		}
+	}
+	for _, v := range c.Reconnect.RetryableReasons {
+		if c.patterns[v] == nil {
+			t.Errorf("retryable reason %q wasn't compiled when loading", v)
+		}
+	}
+
+	if _, err := loadTestConfig(t, "[Reconnect]\nRetryableReasons = [\"(\"]"); err == nil {
+		t.Error("loaded an invalid retryable reason")
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	for _, data := range []string{
+		"[Login]\nPrompt = \"(\"",
+		"[Login]\nFailure = \"[a\"",
+		"[Party]\nInvitePattern = \"(\"",
+		"[Game]\nEndPatterns = [\"ok\", \"(\"]",
+		"[Reconnect]\nTriggers = [\"*\"]",
+	} {
+		if _, err := loadTestConfig(t, data); err == nil {
+			t.Errorf("loaded invalid patterns %q", data)
+		}
+	}
+}
+
+func TestMessageRuleNotLoaded(t *testing.T) {
+	rule := MessageRule{Match: "^(\\w+) joined$", Regex: true, Action: ActionChat, Argument: "hi $1"}
+	if arg, ok := rule.Matches("ptbr", "Steve joined"); !ok || arg != "hi Steve" {
+		t.Errorf("Matches = %q, %v, want hi Steve", arg, ok)
+	}
+	rule.Match = "("
+	if _, ok := rule.Matches("ptbr", "("); ok {
+		t.Error("an invalid pattern matched")
+	}
+}
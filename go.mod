@@ -6,7 +6,9 @@ go 1.18
 require (
 	github.com/g3n/engine v0.2.0
 	github.com/go-gl/mathgl v1.0.0
+	github.com/google/uuid v1.3.0
 	github.com/pelletier/go-toml v1.9.5
+	github.com/sandertv/go-raknet v1.10.9
 	github.com/sandertv/gophertunnel v1.19.11-0.20220601231535-4fdf3713c504
 	github.com/sirupsen/logrus v1.8.1
 	golang.org/x/oauth2 v0.0.0-20220524215830-622c5d57e401
@@ -17,10 +19,8 @@ require (
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20210410170116-ea3d685f79fb // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/google/uuid v1.3.0 // indirect
 	github.com/klauspost/compress v1.15.6 // indirect
 	github.com/muhammadmuzzammil1998/jsonc v1.0.0 // indirect
-	github.com/sandertv/go-raknet v1.10.9 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e // indirect
 	golang.org/x/image v0.0.0-20220601225756-64ec528b34cd // indirect
@@ -0,0 +1,18 @@
+package lang
+
+import (
+	"regexp"
+)
+
+var formatCodes = regexp.MustCompile(`§.?`)
+var ansiCodes = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// StripFormatting removes the Minecraft § colour and style codes from msg
+func StripFormatting(msg string) string {
+	return formatCodes.ReplaceAllString(msg, "")
+}
+
+// StripANSI removes ANSI colour escape codes from msg
+func StripANSI(msg string) string {
+	return ansiCodes.ReplaceAllString(msg, "")
+}
@@ -0,0 +1,21 @@
+package lang
+
+import "testing"
+
+func TestStripFormatting(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"plain", "plain"},
+		{"§aGreen §lbold§r text", "Green bold text"},
+		{"§6§l[Server]§r §eRestarting", "[Server] Restarting"},
+		{"ends with §", "ends with "},
+		{"§ãcçentos", "cçentos"},
+	}
+	for _, test := range tests {
+		if got := StripFormatting(test.msg); got != test.want {
+			t.Errorf("StripFormatting(%q) = %q, want %q", test.msg, got, test.want)
+		}
+	}
+}
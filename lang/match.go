@@ -0,0 +1,28 @@
+package lang
+
+import (
+	"regexp"
+	"strings"
+)
+
+var formatVerbs = regexp.MustCompile(`%(\[\d+\])?[a-z]`)
+
+// MatchesTranslated tells whether message is the message with the given key, either untranslated or
+// translated to lang. Parameters in the translation match anything.
+func MatchesTranslated(lang, key, message string) bool {
+	message = strings.TrimSpace(StripFormatting(message))
+	key = strings.TrimPrefix(key, "%")
+	if strings.TrimPrefix(message, "%") == key {
+		return true
+	}
+	translated := strings.TrimSpace(GetString(lang, key))
+	if translated == key {
+		return false
+	}
+	parts := formatVerbs.Split(strings.ReplaceAll(translated, "%%", "%"), -1)
+	for i, v := range parts {
+		parts[i] = regexp.QuoteMeta(v)
+	}
+	matched, _ := regexp.MatchString("^"+strings.Join(parts, ".*")+"$", message)
+	return matched
+}
@@ -0,0 +1,28 @@
+package lang
+
+import "testing"
+
+func TestMatchesTranslated(t *testing.T) {
+	tests := []struct {
+		lang, key, message string
+		want               bool
+	}{
+		// Untranslated keys are passed through as is, with or without their leading %
+		{"en", "death.attack.player", "%death.attack.player", true},
+		{"en", "%death.attack.player", "death.attack.player", true},
+		{"ptbr", "death.attack.player", "%death.attack.player", true},
+		// There are no translations for en, so a sentence never matches
+		{"en", "death.attack.player", "Steve foi morto(a) por Alex", false},
+		{"ptbr", "death.attack.player", "Steve foi morto(a) por Alex", true},
+		{"ptbr", "death.attack.player", "§cSteve foi morto(a) por Alex§r  ", true},
+		{"ptbr", "multiplayer.player.joined", "Steve entrou no jogo", true},
+		{"ptbr", "multiplayer.player.joined", "Steve saiu do jogo", false},
+		{"ptbr", "death.attack.player", "morreu", false},
+		{"ptbr", "no.such.key", "anything", false},
+	}
+	for _, test := range tests {
+		if got := MatchesTranslated(test.lang, test.key, test.message); got != test.want {
+			t.Errorf("MatchesTranslated(%q, %q, %q) = %v, want %v", test.lang, test.key, test.message, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package lang
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -8,7 +10,7 @@ import (
 // FROM https://github.com/CloudburstMC/Language/blob/master/pt_BR.lang
 
 func GetString(lang, key string) string {
-	if lang != "ptbr" {
+	if lang != "ptbr" || key == "" {
 		return key
 	}
 
@@ -19,13 +21,25 @@ func GetString(lang, key string) string {
 	key = strings.Trim(key, " \r\n")
 
 	val, ok := PTBR[key]
-	if ok {
+	if ok && val != "" {
 		return val
 	}
 
 	return key
 }
 
+// Check returns an error if there are no translations for lang. Keys are passed through untranslated
+// in that case.
+func Check(lang string) error {
+	if lang != "ptbr" {
+		return fmt.Errorf("no translations for %q", lang)
+	}
+	if len(PTBR) == 0 {
+		return errors.New("translations for ptbr are empty")
+	}
+	return nil
+}
+
 var formatter = regexp.MustCompile(`\%([a-zA-Z\.]*)`)
 
 func FormatString(lang, msg string) string {
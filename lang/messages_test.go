@@ -0,0 +1,28 @@
+package lang
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	if err := Check("ptbr"); err != nil {
+		t.Errorf("ptbr: %s", err)
+	}
+	if err := Check("enus"); err == nil {
+		t.Error("no error for a locale without translations")
+	}
+}
+
+func TestEmptyBundle(t *testing.T) {
+	old := PTBR
+	PTBR = map[string]string{}
+	t.Cleanup(func() { PTBR = old })
+
+	if err := Check("ptbr"); err == nil {
+		t.Error("no error for empty translations")
+	}
+	if got := GetString("ptbr", "%multiplayer.player.joined"); got != "multiplayer.player.joined" {
+		t.Errorf("GetString = %q, want the key", got)
+	}
+	if got := FormatString("ptbr", "multiplayer.player.joined"); got != "multiplayer.player.joined" {
+		t.Errorf("FormatString = %q, want the key", got)
+	}
+}
@@ -0,0 +1,94 @@
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer appends to a file, rotating it once it reaches MaxSize bytes. Rotated files are renamed to
+// path.1, path.2 and so on, keeping at most MaxBackups of them, and none older than MaxAge if set.
+type Writer struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+	MaxAge     time.Duration
+
+	lock sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func Open(path string, maxSize int64, maxBackups int, maxAge time.Duration) (*Writer, error) {
+	w := &Writer{
+		Path:       path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+	}
+	return w, w.open()
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.f = f
+	w.size = stat.Size()
+	return nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.size > 0 && w.size+int64(len(p)) > w.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) backup(n int) string {
+	return fmt.Sprintf("%s.%d", w.Path, n)
+}
+
+func (w *Writer) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	_ = os.Remove(w.backup(w.MaxBackups))
+	for i := w.MaxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(w.backup(i), w.backup(i+1))
+	}
+	if w.MaxBackups > 0 {
+		if err := os.Rename(w.Path, w.backup(1)); err != nil {
+			return err
+		}
+	} else {
+		_ = os.Remove(w.Path)
+	}
+	if w.MaxAge > 0 {
+		for i := 1; i <= w.MaxBackups; i++ {
+			if stat, err := os.Stat(w.backup(i)); err == nil && time.Since(stat.ModTime()) > w.MaxAge {
+				_ = os.Remove(w.backup(i))
+			}
+		}
+	}
+	return w.open()
+}
+
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.f.Close()
+}
@@ -0,0 +1,63 @@
+package ping
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandertv/go-raknet"
+)
+
+type ServerStatus struct {
+	Name       string
+	WorldName  string
+	Protocol   int
+	Version    string
+	Players    int
+	MaxPlayers int
+	GameMode   string
+}
+
+// Ping sends an unconnected ping to the server at address and returns its status
+func Ping(address string) (ServerStatus, error) {
+	return PingTimeout(address, time.Second*5)
+}
+
+func PingTimeout(address string, timeout time.Duration) (ServerStatus, error) {
+	data, err := raknet.PingTimeout(address, timeout)
+	if err != nil {
+		return ServerStatus{}, err
+	}
+	return ParsePong(data)
+}
+
+// ParsePong parses the pong data of a Bedrock server, formatted as
+// MCPE;name;protocol;version;players;max players;server id;world name;game mode;...
+func ParsePong(data []byte) (ServerStatus, error) {
+	fields := strings.Split(string(data), ";")
+	if len(fields) < 6 || fields[0] != "MCPE" {
+		return ServerStatus{}, fmt.Errorf("invalid pong data: %q", data)
+	}
+	status := ServerStatus{
+		Name:    fields[1],
+		Version: fields[3],
+	}
+	var err error
+	if status.Protocol, err = strconv.Atoi(fields[2]); err != nil {
+		return status, fmt.Errorf("invalid protocol %q: %w", fields[2], err)
+	}
+	if status.Players, err = strconv.Atoi(fields[4]); err != nil {
+		return status, fmt.Errorf("invalid player count %q: %w", fields[4], err)
+	}
+	if status.MaxPlayers, err = strconv.Atoi(fields[5]); err != nil {
+		return status, fmt.Errorf("invalid max player count %q: %w", fields[5], err)
+	}
+	if len(fields) > 7 {
+		status.WorldName = fields[7]
+	}
+	if len(fields) > 8 {
+		status.GameMode = fields[8]
+	}
+	return status, nil
+}
@@ -0,0 +1,46 @@
+package ping
+
+import "testing"
+
+func TestParsePong(t *testing.T) {
+	got, err := ParsePong([]byte("MCPE;§aMy Server;503;1.18.30;3;20;12345678901234;Survival World;Survival;1;19132;19133;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ServerStatus{
+		Name:       "§aMy Server",
+		WorldName:  "Survival World",
+		Protocol:   503,
+		Version:    "1.18.30",
+		Players:    3,
+		MaxPlayers: 20,
+		GameMode:   "Survival",
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Old servers stop after the max player count
+	got, err = ParsePong([]byte("MCPE;Old;291;1.7.0;0;10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxPlayers != 10 || got.WorldName != "" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParsePongInvalid(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"MCEE;Education;503;1.18.30;3;20",
+		"MCPE;Short;503",
+		"MCPE;Name;new;1.18.30;3;20",
+		"MCPE;Name;503;1.18.30;three;20",
+		"MCPE;Name;503;1.18.30;3;",
+	} {
+		if _, err := ParsePong([]byte(data)); err == nil {
+			t.Errorf("no error parsing %q", data)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists values by key. Get returns an error wrapping os.ErrNotExist for unknown keys.
+type Store interface {
+	Get(key string) ([]byte, error)
+	Set(key string, val []byte) error
+}
+
+// FileStore keeps each key in a file with the same name inside Dir
+type FileStore struct {
+	Dir string
+}
+
+func (s FileStore) Get(key string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(s.Dir, key))
+}
+
+func (s FileStore) Set(key string, val []byte) error {
+	return ioutil.WriteFile(filepath.Join(s.Dir, key), val, 0666)
+}
+
+// MemoryStore keeps values in memory only
+type MemoryStore struct {
+	lock sync.Mutex
+	data map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: map[string][]byte{},
+	}
+}
+
+func (s *MemoryStore) Get(key string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	val, ok := s.data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", key, os.ErrNotExist)
+	}
+	return append([]byte(nil), val...), nil
+}
+
+func (s *MemoryStore) Set(key string, val []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.data[key] = append([]byte(nil), val...)
+	return nil
+}